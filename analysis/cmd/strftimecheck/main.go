@@ -0,0 +1,14 @@
+// Command strftimecheck runs the strftimecheck analyzer. It can be used
+// on its own, or through go vet:
+//
+//	go vet -vettool=$(which strftimecheck) ./...
+package main
+
+import (
+	"github.com/lestrrat-go/strftime/analysis/strftimecheck"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(strftimecheck.Analyzer)
+}
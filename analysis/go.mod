@@ -0,0 +1,18 @@
+module github.com/lestrrat-go/strftime/analysis
+
+go 1.22.0
+
+require (
+	github.com/lestrrat-go/strftime v1.1.1
+	golang.org/x/tools v0.30.0
+)
+
+require (
+	golang.org/x/mod v0.23.0 // indirect
+	golang.org/x/sync v0.11.0 // indirect
+)
+
+// Lint, which the analyzer uses, is not in a released version yet. Until
+// v1.2.0 is tagged, the analyzer is built with the version in this
+// repository
+replace github.com/lestrrat-go/strftime => ../
@@ -0,0 +1,169 @@
+// Package strftimecheck provides a go/analysis Analyzer that validates
+// constant patterns passed to github.com/lestrrat-go/strftime.
+//
+// Patterns given to strftime.New and strftime.Format are compiled using
+// the default specification set at vet time, so that typos are reported
+// before the code is run. Combinations of specifications that are
+// syntactically valid but most likely wrong, such as using the ISO week
+// year %G together with the calendar month %m, are reported as well.
+package strftimecheck
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lestrrat-go/strftime"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const strftimePkgPath = `github.com/lestrrat-go/strftime`
+
+const doc = `check constant strftime patterns
+
+The strftimecheck analyzer checks constant patterns given to strftime.New
+and strftime.Format using strftime.Lint. By default, it reports unknown
+specifications, stray '%' characters, and suspicious combinations of
+specifications such as %G with %m, or %Y with %V. Use -codes to select
+the diagnostics to report.`
+
+// Analyzer is the strftimecheck analyzer
+var Analyzer = &analysis.Analyzer{
+	Name:     "strftimecheck",
+	Doc:      doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// the rest of the diagnostics reported by strftime.Lint are only useful
+// for specific use cases (e.g. file names), and would be too noisy to be
+// enabled by default
+const defaultCodes = `SF000,SF001,SF002`
+
+var enabledCodes = make(map[strftime.DiagnosticCode]struct{})
+
+type codesFlag struct{}
+
+func (codesFlag) String() string {
+	codes := make([]string, 0, len(enabledCodes))
+	for code := range enabledCodes {
+		codes = append(codes, string(code))
+	}
+	sort.Strings(codes)
+	return strings.Join(codes, ",")
+}
+
+func (codesFlag) Set(s string) error {
+	for code := range enabledCodes {
+		delete(enabledCodes, code)
+	}
+	for _, code := range strings.Split(s, ",") {
+		if code = strings.TrimSpace(code); code != "" {
+			enabledCodes[strftime.DiagnosticCode(code)] = struct{}{}
+		}
+	}
+	return nil
+}
+
+func init() {
+	_ = codesFlag{}.Set(defaultCodes)
+	Analyzer.Flags.Var(codesFlag{}, "codes", "comma separated list of strftime.Lint diagnostic codes to report")
+}
+
+// functions whose first argument is a pattern
+var patternFuncs = map[string]struct{}{
+	`New`:    {},
+	`Format`: {},
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	nodeFilter := []ast.Node{
+		(*ast.CallExpr)(nil),
+	}
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		sig, ok := patternFuncSignature(pass, call)
+		if !ok || len(call.Args) < 1 {
+			return
+		}
+
+		arg := call.Args[0]
+		tv, ok := pass.TypesInfo.Types[arg]
+		if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+			return
+		}
+		// the last parameter is always the variadic list of options
+		hasOptions := len(call.Args) >= sig.Params().Len()
+		checkPattern(pass, arg, constant.StringVal(tv.Value), hasOptions)
+	})
+	return nil, nil
+}
+
+// patternFuncSignature returns the signature of the function being called,
+// if it is one of the functions that take a pattern
+func patternFuncSignature(pass *analysis.Pass, call *ast.CallExpr) (*types.Signature, bool) {
+	var ident *ast.Ident
+	switch fun := call.Fun.(type) {
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	case *ast.Ident:
+		ident = fun
+	default:
+		return nil, false
+	}
+
+	fn, ok := pass.TypesInfo.Uses[ident].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != strftimePkgPath {
+		return nil, false
+	}
+	if _, ok := patternFuncs[fn.Name()]; !ok {
+		return nil, false
+	}
+	// methods such as (*Strftime).Format do not take a pattern
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok || sig.Recv() != nil || !sig.Variadic() {
+		return nil, false
+	}
+	return sig, true
+}
+
+// posInPattern returns the position of the byte at offset within the
+// pattern. If the pattern is not a simple string literal, the position
+// of the expression itself is returned.
+func posInPattern(expr ast.Expr, offset int) token.Pos {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING || len(lit.Value) < 2 {
+		return expr.Pos()
+	}
+	if lit.Value[0] != '`' {
+		// escape sequences change the offsets, so only use them if there are none
+		unquoted, err := strconv.Unquote(lit.Value)
+		if err != nil || unquoted != lit.Value[1:len(lit.Value)-1] {
+			return expr.Pos()
+		}
+	}
+	return lit.Pos() + 1 + token.Pos(offset)
+}
+
+// checkPattern reports problems in the pattern. If options were passed,
+// they may register custom specifications, so specifications that are
+// missing from the default specification set are not reported.
+func checkPattern(pass *analysis.Pass, expr ast.Expr, pattern string, hasOptions bool) {
+	for _, d := range strftime.Lint(pattern) {
+		if _, ok := enabledCodes[d.Code]; !ok {
+			continue
+		}
+		if hasOptions && d.Code == strftime.LintUnknownSpecification {
+			continue
+		}
+		pass.Reportf(posInPattern(expr, d.Start), "strftime pattern %q: %s (%s)", pattern, d.Message, d.Code)
+	}
+}
@@ -0,0 +1,12 @@
+package strftimecheck_test
+
+import (
+	"testing"
+
+	"github.com/lestrrat-go/strftime/analysis/strftimecheck"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), strftimecheck.Analyzer, "a")
+}
@@ -0,0 +1,24 @@
+package a
+
+import (
+	"time"
+
+	"github.com/lestrrat-go/strftime"
+)
+
+const datePattern = `%Y-%m-%d`
+
+func patterns() {
+	strftime.New(`%Y-%m-%d %H:%M:%S`)
+	strftime.New(datePattern)
+	strftime.New(`%Y-%m-%d %Q`) // want `'%Q' was not found`
+	strftime.New("%Y-%m-%d %")  // want `stray % at the end of pattern`
+	strftime.Format(`%L`, time.Now(), strftime.WithMilliseconds('L'))
+	strftime.Format(`%L`, time.Now()) // want `'%L' was not found`
+	strftime.New(`%G-%m-%d`)          // want `%G \(ISO week year\) is mixed with %m \(calendar date\)`
+	strftime.New(`%Y-W%V`)            // want `%Y \(calendar year\) is mixed with %V \(ISO week number\)`
+	strftime.New(`%G-W%V-%u`)
+
+	var p string
+	strftime.New(p)
+}
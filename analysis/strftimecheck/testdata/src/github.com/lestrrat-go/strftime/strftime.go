@@ -0,0 +1,19 @@
+// Package strftime is a stub of github.com/lestrrat-go/strftime for tests
+package strftime
+
+import (
+	"io"
+	"time"
+)
+
+type Option interface{}
+
+type Strftime struct{}
+
+func New(string, ...Option) (*Strftime, error) { return nil, nil }
+
+func Format(string, time.Time, ...Option) (string, error) { return "", nil }
+
+func WithMilliseconds(byte) Option { return nil }
+
+func (*Strftime) Format(io.Writer, time.Time) error { return nil }
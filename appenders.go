@@ -102,6 +102,8 @@ func (l appenderList) dump(out io.Writer) {
 // does the time.Format thing
 type stdlibFormat struct {
 	s string
+	// the layout compiled for appendCivil, or nil if it is not supported
+	civil []civilLayoutElement
 }
 
 // StdlibFormat returns an Appender that simply goes through `time.Format()`
@@ -113,7 +115,7 @@ type stdlibFormat struct {
 // ss := NewSpecificationSet()
 // ss.Set('b', a) // does %b -> abbreviated month name
 func StdlibFormat(s string) Appender {
-	return &stdlibFormat{s: s}
+	return &stdlibFormat{s: s, civil: compileCivilLayout(s)}
 }
 
 func (v stdlibFormat) Append(b []byte, t time.Time) []byte {
@@ -378,3 +380,234 @@ func (v hmsWAMPM) Append(b []byte, t time.Time) []byte {
 
 	return b
 }
+
+// flaggedAppender applies the flags and the field width given in the
+// pattern (e.g. `%-d`, `%_5H`, `%^a`) to the output of another Appender.
+// This is done by post-processing the bytes that the wrapped Appender
+// produced, so that any Appender, including user supplied ones, can be
+// used with flags.
+type flaggedAppender struct {
+	appender Appender
+	flags    string
+	width    int
+}
+
+// widthAppender is implemented by Appenders that interpret the field
+// width by themselves, instead of having their output padded. For
+// example, the width of FractionalSeconds is the number of digits.
+type widthAppender interface {
+	appendWidth([]byte, time.Time, int) []byte
+}
+
+func (v flaggedAppender) Append(b []byte, t time.Time) []byte {
+	start := len(b)
+	if wa, ok := v.appender.(widthAppender); ok && v.width > 0 {
+		b = wa.appendWidth(b, t, v.width)
+		return applyFlags(b, start, v.flags, 0)
+	}
+	b = v.appender.Append(b, t)
+	return applyFlags(b, start, v.flags, v.width)
+}
+
+func (v flaggedAppender) isZero(t time.Time) (bool, bool) {
+	return reportZero(v.appender, t)
+}
+
+func (v flaggedAppender) dump(out io.Writer) {
+	fmt.Fprintf(out, "flags(%q, width=%d): ", v.flags, v.width)
+	if d, ok := v.appender.(dumper); ok {
+		d.dump(out)
+	} else {
+		fmt.Fprintf(out, "%#v", v.appender)
+	}
+}
+
+// isPaddedNumber reports if s is a number, possibly padded with leading
+// zeros or blanks
+func isPaddedNumber(s []byte) bool {
+	i := 0
+	for i < len(s) && s[i] == ' ' {
+		i++
+	}
+	if i == len(s) {
+		return false
+	}
+	for ; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// applyFlags modifies b[start:] according to flags and width
+func applyFlags(b []byte, start int, flags string, width int) []byte {
+	var pad byte
+	var upper, swap bool
+	for i := 0; i < len(flags); i++ {
+		switch c := flags[i]; c {
+		case '-', '_', '0':
+			pad = c
+		case '^':
+			upper = true
+		case '#':
+			swap = true
+		}
+	}
+
+	field := b[start:]
+	numeric := isPaddedNumber(field)
+	if numeric && pad != 0 {
+		// find the padding that the Appender produced, but always
+		// leave at least one digit
+		i := 0
+		for i < len(field)-1 && (field[i] == ' ' || field[i] == '0') {
+			i++
+		}
+		switch pad {
+		case '-':
+			b = append(b[:start], field[i:]...)
+		case '_':
+			for j := 0; j < i; j++ {
+				field[j] = ' '
+			}
+		case '0':
+			for j := 0; j < i; j++ {
+				field[j] = '0'
+			}
+		}
+	}
+
+	if l := len(b) - start; pad != '-' && width > l {
+		// numbers are padded with zeros, unless the Appender itself
+		// chose to pad with blanks (e.g. %e)
+		padc := byte(' ')
+		if pad == '0' || (pad == 0 && numeric && b[start] != ' ') {
+			padc = '0'
+		}
+		n := width - l
+		for i := 0; i < n; i++ {
+			b = append(b, 0)
+		}
+		copy(b[start+n:], b[start:start+l])
+		for i := 0; i < n; i++ {
+			b[start+i] = padc
+		}
+	}
+
+	switch {
+	case upper:
+		for i := start; i < len(b); i++ {
+			if c := b[i]; c >= 'a' && c <= 'z' {
+				b[i] = c - ('a' - 'A')
+			}
+		}
+	case swap:
+		// like GNU, '#' converts to lower case if the field is all
+		// upper case (e.g. %Z), and to upper case otherwise (e.g. %a)
+		hasLower := false
+		for i := start; i < len(b); i++ {
+			if c := b[i]; c >= 'a' && c <= 'z' {
+				hasLower = true
+				break
+			}
+		}
+		for i := start; i < len(b); i++ {
+			c := b[i]
+			if hasLower && c >= 'a' && c <= 'z' {
+				b[i] = c - ('a' - 'A')
+			} else if !hasLower && c >= 'A' && c <= 'Z' {
+				b[i] = c + ('a' - 'A')
+			}
+		}
+	}
+	return b
+}
+
+// inLocation renders the wrapped Appender in a different location. It is
+// created by the compiler for specifications that follow `%{in:Location}`
+type inLocation struct {
+	loc      *time.Location
+	appender Appender
+}
+
+func (v inLocation) Append(b []byte, t time.Time) []byte {
+	return v.appender.Append(b, t.In(v.loc))
+}
+
+func (v inLocation) isZero(t time.Time) (bool, bool) {
+	return reportZero(v.appender, t.In(v.loc))
+}
+
+func (v inLocation) dump(out io.Writer) {
+	fmt.Fprintf(out, "in(%s): ", v.loc)
+	if d, ok := v.appender.(dumper); ok {
+		d.dump(out)
+	} else {
+		fmt.Fprintf(out, "%#v", v.appender)
+	}
+}
+
+// ZeroReporter is an optional interface that Appenders can implement to
+// report that the value they would render for a given time is zero, such
+// as the fractional part of a time that has no nanoseconds.
+//
+// Optional sections in patterns (`%[...%]`) use this to decide if their
+// content should be omitted.
+type ZeroReporter interface {
+	IsZero(time.Time) bool
+}
+
+// zeroReporter is implemented by the Appenders that wrap other Appenders.
+// The second return value is false if there was nothing to report
+type zeroReporter interface {
+	isZero(time.Time) (bool, bool)
+}
+
+func reportZero(a Appender, t time.Time) (bool, bool) {
+	switch a := a.(type) {
+	case zeroReporter:
+		return a.isZero(t)
+	case ZeroReporter:
+		return a.IsZero(t), true
+	}
+	return false, false
+}
+
+// optionalSection is created by the compiler for `%[...%]`. Its content is
+// omitted if all of the Appenders within that can report a zero value do
+// so. If none of them can, the content is always rendered.
+type optionalSection struct {
+	list appenderList
+}
+
+func (v optionalSection) isZero(t time.Time) (bool, bool) {
+	var reported bool
+	for _, a := range v.list {
+		zero, ok := reportZero(a, t)
+		if !ok {
+			continue
+		}
+		if !zero {
+			return false, true
+		}
+		reported = true
+	}
+	return reported, reported
+}
+
+func (v optionalSection) Append(b []byte, t time.Time) []byte {
+	if zero, _ := v.isZero(t); zero {
+		return b
+	}
+	for _, a := range v.list {
+		b = a.Append(b, t)
+	}
+	return b
+}
+
+func (v optionalSection) dump(out io.Writer) {
+	fmt.Fprintf(out, "optional: [\n")
+	v.list.dump(out)
+	fmt.Fprintf(out, "\n]")
+}
@@ -0,0 +1,73 @@
+package strftime
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// FormatAll formats each of the times, and appends the results to `dst`.
+// The second return value contains the offsets of each result in the
+// returned slice: the i-th result is out[offsets[i]:offsets[i+1]], and
+// offsets has len(ts)+1 elements.
+func (f *Strftime) FormatAll(dst []byte, ts []time.Time) ([]byte, []int) {
+	offsets := make([]int, 0, len(ts)+1)
+	for _, t := range ts {
+		offsets = append(offsets, len(dst))
+		dst = f.format(dst, t)
+	}
+	offsets = append(offsets, len(dst))
+	return dst, offsets
+}
+
+// the minimum number of times that each goroutine formats in
+// FormatAllParallel. Below this, the overhead of the goroutines
+// outweighs the gain
+const minParallelChunk = 1024
+
+// FormatAllParallel is equivalent to FormatAll, but formats the times
+// using up to `n` goroutines. If `n` is less than 1, runtime.GOMAXPROCS(0)
+// is used. The output is identical to that of FormatAll, regardless of
+// the number of goroutines.
+func (f *Strftime) FormatAllParallel(dst []byte, ts []time.Time, n int) ([]byte, []int) {
+	if n < 1 {
+		n = runtime.GOMAXPROCS(0)
+	}
+	if max := len(ts) / minParallelChunk; n > max {
+		n = max
+	}
+	if n < 2 {
+		return f.FormatAll(dst, ts)
+	}
+
+	// each goroutine formats a contiguous chunk into its own buffer, and
+	// the buffers are concatenated in order
+	type chunk struct {
+		buf     []byte
+		offsets []int
+	}
+	chunks := make([]chunk, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		// the boundaries are spread evenly, so that no chunk is empty
+		start := i * len(ts) / n
+		end := (i + 1) * len(ts) / n
+		wg.Add(1)
+		go func(c *chunk, ts []time.Time) {
+			defer wg.Done()
+			c.buf, c.offsets = f.FormatAll(make([]byte, 0, len(ts)*(len(f.pattern)+10)), ts)
+		}(&chunks[i], ts[start:end])
+	}
+	wg.Wait()
+
+	offsets := make([]int, 0, len(ts)+1)
+	for _, c := range chunks {
+		base := len(dst)
+		for _, o := range c.offsets[:len(c.offsets)-1] {
+			offsets = append(offsets, base+o)
+		}
+		dst = append(dst, c.buf...)
+	}
+	offsets = append(offsets, len(dst))
+	return dst, offsets
+}
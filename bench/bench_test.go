@@ -101,3 +101,52 @@ func BenchmarkLestrratCachedFormatBuffer(b *testing.B) {
 		buf = f.FormatBuffer(buf[:0], t)
 	}
 }
+
+func benchTimes() []time.Time {
+	ts := make([]time.Time, 100000)
+	base := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for i := range ts {
+		ts[i] = base.Add(time.Duration(i) * time.Second)
+	}
+	return ts
+}
+
+func BenchmarkLestrratFormatBufferLoop(b *testing.B) {
+	ts := benchTimes()
+	f, _ := lestrrat.New(benchfmt)
+	var buf []byte
+	offsets := make([]int, 0, len(ts)+1)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		buf = buf[:0]
+		offsets = offsets[:0]
+		for _, t := range ts {
+			offsets = append(offsets, len(buf))
+			buf = f.FormatBuffer(buf, t)
+		}
+		offsets = append(offsets, len(buf))
+	}
+}
+
+func BenchmarkLestrratFormatAll(b *testing.B) {
+	ts := benchTimes()
+	f, _ := lestrrat.New(benchfmt)
+	var buf []byte
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		buf, _ = f.FormatAll(buf[:0], ts)
+	}
+}
+
+func BenchmarkLestrratFormatAllParallel(b *testing.B) {
+	ts := benchTimes()
+	f, _ := lestrrat.New(benchfmt)
+	var buf []byte
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		buf, _ = f.FormatAllParallel(buf[:0], ts, 0)
+	}
+}
@@ -9,3 +9,5 @@ require (
 	github.com/ncruces/go-strftime v0.1.9
 	github.com/tebeka/strftime v0.1.5
 )
+
+replace github.com/lestrrat-go/strftime => ../
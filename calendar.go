@@ -0,0 +1,383 @@
+package strftime
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Calendar converts times to dates in a calendar other than the
+// (proleptic) Gregorian calendar. When a Calendar is specified using
+// WithCalendar, the date related specifications (%Y, %y, %m, %d, %e,
+// %B, %b, %h, %j, and the composite %F, %D, %x, %v and %c) consult it
+// instead of using the Gregorian date. The rest of the specifications are
+// not affected.
+type Calendar interface {
+	// Name returns the name of the calendar, for diagnostic purposes
+	Name() string
+	// Date returns the year, the month (1-based), the day of the month,
+	// and the day of the year of the date of t, in t's location
+	Date(t time.Time) (year, month, day, yday int)
+	// MonthName returns the full name of the month, as returned by Date
+	MonthName(month int) string
+	// AbbreviatedMonthName returns the abbreviated name of the month, as
+	// returned by Date
+	AbbreviatedMonthName(month int) string
+	// Era returns the name of the era that the year returned by Date
+	// counts from (e.g. "Reiwa" for the Japanese calendar). It is
+	// rendered using `%{era}`
+	Era(t time.Time) string
+}
+
+// the specifications that consult the Calendar
+const calendarSpecifications = `YymdeBbhjFDxvc`
+
+// namedEra is the name of the default named specification `%{era}`,
+// which renders the era of the Calendar given by WithCalendar
+const namedEra = `era`
+
+type calendarAppender struct {
+	calendar      Calendar
+	specification byte
+}
+
+func appendCalendarYear(b []byte, year int) []byte {
+	if year < 0 {
+		b = append(b, '-')
+		year = -year
+	}
+	return strconv.AppendInt(b, int64(year), 10)
+}
+
+func appendCalendarDaySpacePad(b []byte, day int) []byte {
+	if day < 10 {
+		return append(b, ' ', byte('0'+day))
+	}
+	return unrollTwoDigits(b, day)
+}
+
+func (v calendarAppender) Append(b []byte, t time.Time) []byte {
+	year, month, day, yday := v.calendar.Date(t)
+	switch v.specification {
+	case 'Y':
+		return appendCalendarYear(b, year)
+	case 'y':
+		if year < 0 {
+			year = -year
+		}
+		return unrollTwoDigits(b, year%100)
+	case 'm':
+		return unrollTwoDigits(b, month)
+	case 'd':
+		return unrollTwoDigits(b, day)
+	case 'e':
+		return appendCalendarDaySpacePad(b, day)
+	case 'B':
+		return append(b, v.calendar.MonthName(month)...)
+	case 'b', 'h':
+		return append(b, v.calendar.AbbreviatedMonthName(month)...)
+	case 'v':
+		b = appendCalendarDaySpacePad(b, day)
+		b = append(b, '-')
+		b = append(b, v.calendar.AbbreviatedMonthName(month)...)
+		b = append(b, '-')
+		return appendCalendarYear(b, year)
+	case 'c':
+		// same layout as the Gregorian %c: "Mon Jan _2 15:04:05 2006"
+		b = append(b, t.Weekday().String()[:3]...)
+		b = append(b, ' ')
+		b = append(b, v.calendar.AbbreviatedMonthName(month)...)
+		b = append(b, ' ')
+		b = appendCalendarDaySpacePad(b, day)
+		b = append(b, ' ')
+		b = unrollTwoDigits(b, t.Hour())
+		b = append(b, ':')
+		b = unrollTwoDigits(b, t.Minute())
+		b = append(b, ':')
+		b = unrollTwoDigits(b, t.Second())
+		b = append(b, ' ')
+		return appendCalendarYear(b, year)
+	case 'j':
+		b = append(b, byte('0'+yday/100))
+		return unrollTwoDigits(b, yday%100)
+	case 'F':
+		b = appendCalendarYear(b, year)
+		b = append(b, '-')
+		b = unrollTwoDigits(b, month)
+		b = append(b, '-')
+		return unrollTwoDigits(b, day)
+	default: // 'D', 'x'
+		if year < 0 {
+			year = -year
+		}
+		b = unrollTwoDigits(b, month)
+		b = append(b, '/')
+		b = unrollTwoDigits(b, day)
+		b = append(b, '/')
+		return unrollTwoDigits(b, year%100)
+	}
+}
+
+func (v calendarAppender) describe() string {
+	return fmt.Sprintf(`%s, in the %s calendar`, specificationDescriptions[v.specification], v.calendar.Name())
+}
+
+type eraAppender struct {
+	calendar Calendar
+}
+
+func (v eraAppender) Append(b []byte, t time.Time) []byte {
+	return append(b, v.calendar.Era(t)...)
+}
+
+func (v eraAppender) describe() string {
+	return fmt.Sprintf(`the era in the %s calendar`, v.calendar.Name())
+}
+
+// gregorianOffsetCalendar is a calendar that only differs from the
+// Gregorian calendar by the year that it counts from
+type gregorianOffsetCalendar struct {
+	name string
+	// returns the year and the era of the date of t
+	year func(t time.Time) (int, string)
+}
+
+func (c gregorianOffsetCalendar) Name() string {
+	return c.name
+}
+
+func (c gregorianOffsetCalendar) Date(t time.Time) (int, int, int, int) {
+	_, m, d := t.Date()
+	y, _ := c.year(t)
+	return y, int(m), d, t.YearDay()
+}
+
+func (c gregorianOffsetCalendar) MonthName(month int) string {
+	return time.Month(month).String()
+}
+
+func (c gregorianOffsetCalendar) AbbreviatedMonthName(month int) string {
+	return time.Month(month).String()[:3]
+}
+
+func (c gregorianOffsetCalendar) Era(t time.Time) string {
+	_, era := c.year(t)
+	return era
+}
+
+type japaneseEra struct {
+	name  string
+	start time.Time
+}
+
+// the first day of each era, in JST. Years before the Meiji era are
+// rendered in the Gregorian calendar
+var japaneseEras = []japaneseEra{
+	{`Reiwa`, time.Date(2019, time.May, 1, 0, 0, 0, 0, time.UTC)},
+	{`Heisei`, time.Date(1989, time.January, 8, 0, 0, 0, 0, time.UTC)},
+	{`Showa`, time.Date(1926, time.December, 25, 0, 0, 0, 0, time.UTC)},
+	{`Taisho`, time.Date(1912, time.July, 30, 0, 0, 0, 0, time.UTC)},
+	{`Meiji`, time.Date(1868, time.January, 1, 0, 0, 0, 0, time.UTC)},
+}
+
+func japaneseYear(t time.Time) (int, string) {
+	y, m, d := t.Date()
+	// compare the dates, regardless of the location of t
+	date := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	for _, era := range japaneseEras {
+		if !date.Before(era.start) {
+			return y - era.start.Year() + 1, era.name
+		}
+	}
+	return y, `AD`
+}
+
+var japaneseCalendar = &gregorianOffsetCalendar{name: `Japanese`, year: japaneseYear}
+
+var buddhistCalendar = &gregorianOffsetCalendar{
+	name: `Thai Buddhist`,
+	year: func(t time.Time) (int, string) {
+		return t.Year() + 543, `BE`
+	},
+}
+
+var minguoCalendar = &gregorianOffsetCalendar{
+	name: `Minguo`,
+	year: func(t time.Time) (int, string) {
+		y := t.Year()
+		if y <= 1911 {
+			return 1912 - y, `Before ROC`
+		}
+		return y - 1911, `ROC`
+	},
+}
+
+// JapaneseCalendar returns the Calendar that counts years from the start
+// of the Japanese imperial eras (Meiji onwards), such as Reiwa 5 for
+// 2023. Months and days are the same as the Gregorian calendar. The era
+// names are romanized (e.g. "Reiwa").
+func JapaneseCalendar() Calendar {
+	return japaneseCalendar
+}
+
+// BuddhistCalendar returns the Calendar that counts years in the Thai
+// Buddhist Era (BE), which is 543 years ahead of the Gregorian year.
+// Months and days are the same as the Gregorian calendar.
+func BuddhistCalendar() Calendar {
+	return buddhistCalendar
+}
+
+// MinguoCalendar returns the Calendar that counts years in the Minguo
+// (Republic of China) era, which starts in 1912. Months and days are the
+// same as the Gregorian calendar.
+func MinguoCalendar() Calendar {
+	return minguoCalendar
+}
+
+var hijriMonthNames = [...]string{
+	`Muharram`, `Safar`, `Rabi' al-awwal`, `Rabi' al-thani`,
+	`Jumada al-awwal`, `Jumada al-thani`, `Rajab`, `Sha'ban`,
+	`Ramadan`, `Shawwal`, `Dhu al-Qi'dah`, `Dhu al-Hijjah`,
+}
+
+var hijriAbbreviatedMonthNames = [...]string{
+	`Muh.`, `Saf.`, `Rab. I`, `Rab. II`,
+	`Jum. I`, `Jum. II`, `Raj.`, `Sha.`,
+	`Ram.`, `Shaw.`, `Dhu'l-Q.`, `Dhu'l-H.`,
+}
+
+// the Julian Day Number of 1 Muharram, 1 AH (July 16, 622 in the Julian
+// calendar), using the civil epoch
+const hijriEpoch = 1948440
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
+
+// hijriToJDN returns the Julian Day Number of the given date in the
+// tabular Islamic calendar
+func hijriToJDN(y, m, d int) int {
+	return d + (59*(m-1)+1)/2 + (y-1)*354 + floorDiv(3+11*y, 30) + hijriEpoch - 1
+}
+
+// tabularHijriDate returns the date of the given Julian Day Number in the
+// tabular Islamic calendar
+func tabularHijriDate(jdn int) (int, int, int, int) {
+	year := floorDiv(30*(jdn-hijriEpoch)+10646, 10631)
+	month := 1
+	for month < 12 && jdn >= hijriToJDN(year, month+1, 1) {
+		month++
+	}
+	day := jdn - hijriToJDN(year, month, 1) + 1
+	yday := jdn - hijriToJDN(year, 1, 1) + 1
+	return year, month, day, yday
+}
+
+// julianDayNumber returns the Julian Day Number of the date of t, in t's
+// location
+func julianDayNumber(t time.Time) int {
+	y, m, d := t.Date()
+	return int(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix()/86400) + 2440588
+}
+
+func hijriMonthName(month int) string {
+	if month < 1 || month > len(hijriMonthNames) {
+		return ``
+	}
+	return hijriMonthNames[month-1]
+}
+
+func hijriAbbreviatedMonthName(month int) string {
+	if month < 1 || month > len(hijriAbbreviatedMonthNames) {
+		return ``
+	}
+	return hijriAbbreviatedMonthNames[month-1]
+}
+
+type tabularHijriCalendar struct{}
+
+func (tabularHijriCalendar) Name() string {
+	return `Hijri (tabular)`
+}
+
+func (tabularHijriCalendar) Date(t time.Time) (int, int, int, int) {
+	return tabularHijriDate(julianDayNumber(t))
+}
+
+func (tabularHijriCalendar) MonthName(month int) string {
+	return hijriMonthName(month)
+}
+
+func (tabularHijriCalendar) AbbreviatedMonthName(month int) string {
+	return hijriAbbreviatedMonthName(month)
+}
+
+func (tabularHijriCalendar) Era(time.Time) string {
+	return `AH`
+}
+
+// the year and the month of the first entry in ummAlQuraMonthStarts,
+// counted in months since 1 Muharram, 1 AH
+const ummAlQuraFirstMonth = (1356 - 1) * 12
+
+type ummAlQuraCalendar struct{}
+
+func (ummAlQuraCalendar) Name() string {
+	return `Hijri (Umm al-Qura)`
+}
+
+func (ummAlQuraCalendar) Date(t time.Time) (int, int, int, int) {
+	jdn := julianDayNumber(t)
+	mcjdn := jdn - 2400000
+	last := len(ummAlQuraMonthStarts) - 1
+	if mcjdn < int(ummAlQuraMonthStarts[0]) || mcjdn >= int(ummAlQuraMonthStarts[last]) {
+		return tabularHijriDate(jdn)
+	}
+
+	// the month that starts on or before the date
+	i := sort.Search(last, func(i int) bool {
+		return int(ummAlQuraMonthStarts[i+1]) > mcjdn
+	})
+	months := ummAlQuraFirstMonth + i
+	year := months/12 + 1
+	month := months%12 + 1
+	day := mcjdn - int(ummAlQuraMonthStarts[i]) + 1
+	yday := mcjdn - int(ummAlQuraMonthStarts[i-(month-1)]) + 1
+	return year, month, day, yday
+}
+
+func (ummAlQuraCalendar) MonthName(month int) string {
+	return hijriMonthName(month)
+}
+
+func (ummAlQuraCalendar) AbbreviatedMonthName(month int) string {
+	return hijriAbbreviatedMonthName(month)
+}
+
+func (ummAlQuraCalendar) Era(time.Time) string {
+	return `AH`
+}
+
+// HijriCalendar returns the Calendar that renders dates in the Islamic
+// (Hijri) calendar, following the Umm al-Qura calendar of Saudi Arabia.
+// The dates are looked up in the published table of the first day of
+// each month, which covers 1356 AH to 1500 AH (14 March 1937 to 16
+// November 2077). Dates outside of the table are rendered in the tabular
+// calendar (see TabularHijriCalendar).
+func HijriCalendar() Calendar {
+	return ummAlQuraCalendar{}
+}
+
+// TabularHijriCalendar returns the Calendar that renders dates in the
+// tabular (arithmetic) Islamic calendar, with the civil epoch and the
+// common leap year cycle (2, 5, 7, 10, 13, 16, 18, 21, 24, 26 and 29 in
+// each 30 years). Dates may differ from those of calendars based on
+// observation, such as Umm al-Qura, by a day or two.
+func TabularHijriCalendar() Calendar {
+	return tabularHijriCalendar{}
+}
@@ -0,0 +1,6 @@
+// Package example contains code generated by strftime-gen, along with
+// the generated tests that verify it against the library.
+package example
+
+//go:generate go run github.com/lestrrat-go/strftime/cmd/strftime-gen -pattern "%Y-%m-%dT%H:%M:%S%z" -func AppendTimestamp -o timestamp_gen.go -test
+//go:generate go run github.com/lestrrat-go/strftime/cmd/strftime-gen -pattern "%A %a %B %b %C %c %D %d %e %F %G %g %H %h %I %j %k %l %M %m %n %p %R %r %S %T %t %U %u %V %v %W %w %X %x %Y %y %Z %z %%" -func AppendEverything -o everything_gen.go -test
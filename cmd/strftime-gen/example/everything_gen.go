@@ -0,0 +1,404 @@
+// Code generated by strftime-gen -pattern "%A %a %B %b %C %c %D %d %e %F %G %g %H %h %I %j %k %l %M %m %n %p %R %r %S %T %t %U %u %V %v %W %w %X %x %Y %y %Z %z %%" -func AppendEverything -o everything_gen.go -test; DO NOT EDIT.
+
+package example
+
+import (
+	"strconv"
+	"time"
+)
+
+// AppendEverything appends the textual representation of t to b using the
+// strftime pattern "%A %a %B %b %C %c %D %d %e %F %G %g %H %h %I %j %k %l %M %m %n %p %R %r %S %T %t %U %u %V %v %W %w %X %x %Y %y %Z %z %%", and returns the extended buffer.
+func AppendEverything(b []byte, t time.Time) []byte {
+	year, month, day := t.Date()
+	hour, minute, second := t.Clock()
+	wday := t.Weekday()
+	yday := t.YearDay()
+	isoYear, isoWeek := t.ISOWeek()
+	zoneName, zoneOffset := t.Zone()
+
+	// %A
+	b = append(b, wday.String()...)
+	b = append(b, " "...)
+	// %a
+	b = append(b, wday.String()[:3]...)
+	b = append(b, " "...)
+	// %B
+	b = append(b, month.String()...)
+	b = append(b, " "...)
+	// %b
+	b = append(b, month.String()[:3]...)
+	b = append(b, " "...)
+	// %C
+	{
+		n := year / 100
+		if n < 10 {
+			b = append(b, '0')
+		}
+		b = strconv.AppendInt(b, int64(n), 10)
+	}
+	b = append(b, " "...)
+	// %a
+	b = append(b, wday.String()[:3]...)
+	b = append(b, " "...)
+	// %b
+	b = append(b, month.String()[:3]...)
+	b = append(b, " "...)
+	// %e
+	if day < 10 {
+		b = append(b, ' ', byte('0'+day))
+	} else {
+		b = append(b, byte('0'+day/10), byte('0'+day%10))
+	}
+	b = append(b, " "...)
+	// %H
+	b = append(b, byte('0'+hour/10), byte('0'+hour%10))
+	b = append(b, ":"...)
+	// %M
+	b = append(b, byte('0'+minute/10), byte('0'+minute%10))
+	b = append(b, ":"...)
+	// %S
+	b = append(b, byte('0'+second/10), byte('0'+second%10))
+	b = append(b, " "...)
+	// %Y
+	{
+		y := year
+		if y < 0 {
+			b = append(b, '-')
+			y = -y
+		}
+		switch {
+		case y < 10:
+			b = append(b, '0', '0', '0')
+		case y < 100:
+			b = append(b, '0', '0')
+		case y < 1000:
+			b = append(b, '0')
+		}
+		b = strconv.AppendInt(b, int64(y), 10)
+	}
+	b = append(b, " "...)
+	// %m
+	b = append(b, byte('0'+int(month)/10), byte('0'+int(month)%10))
+	b = append(b, "/"...)
+	// %d
+	b = append(b, byte('0'+day/10), byte('0'+day%10))
+	b = append(b, "/"...)
+	// %y
+	{
+		y := year % 100
+		if y < 0 {
+			b = append(b, '-')
+			y = -y
+		}
+		b = append(b, byte('0'+y/10), byte('0'+y%10))
+	}
+	b = append(b, " "...)
+	// %d
+	b = append(b, byte('0'+day/10), byte('0'+day%10))
+	b = append(b, " "...)
+	// %e
+	if day < 10 {
+		b = append(b, ' ', byte('0'+day))
+	} else {
+		b = append(b, byte('0'+day/10), byte('0'+day%10))
+	}
+	b = append(b, " "...)
+	// %Y
+	{
+		y := year
+		if y < 0 {
+			b = append(b, '-')
+			y = -y
+		}
+		switch {
+		case y < 10:
+			b = append(b, '0', '0', '0')
+		case y < 100:
+			b = append(b, '0', '0')
+		case y < 1000:
+			b = append(b, '0')
+		}
+		b = strconv.AppendInt(b, int64(y), 10)
+	}
+	b = append(b, "-"...)
+	// %m
+	b = append(b, byte('0'+int(month)/10), byte('0'+int(month)%10))
+	b = append(b, "-"...)
+	// %d
+	b = append(b, byte('0'+day/10), byte('0'+day%10))
+	b = append(b, " "...)
+	// %G
+	{
+		y := isoYear
+		if y < 0 {
+			b = append(b, '-')
+			y = -y
+		}
+		switch {
+		case y < 10:
+			b = append(b, '0', '0', '0')
+		case y < 100:
+			b = append(b, '0', '0')
+		case y < 1000:
+			b = append(b, '0')
+		}
+		b = strconv.AppendInt(b, int64(y), 10)
+	}
+	b = append(b, " "...)
+	// %g
+	{
+		y := isoYear % 100
+		if y < 0 {
+			b = append(b, '-')
+			y = -y
+		}
+		b = append(b, byte('0'+y/10), byte('0'+y%10))
+	}
+	b = append(b, " "...)
+	// %H
+	b = append(b, byte('0'+hour/10), byte('0'+hour%10))
+	b = append(b, " "...)
+	// %b
+	b = append(b, month.String()[:3]...)
+	b = append(b, " "...)
+	// %I
+	{
+		h := hour
+		if h > 12 {
+			h -= 12
+		}
+		if h == 0 {
+			h = 12
+		}
+		b = append(b, byte('0'+h/10), byte('0'+h%10))
+	}
+	b = append(b, " "...)
+	// %j
+	b = append(b, byte('0'+yday/100), byte('0'+yday/10%10), byte('0'+yday%10))
+	b = append(b, " "...)
+	// %k
+	if hour < 10 {
+		b = append(b, ' ', byte('0'+hour))
+	} else {
+		b = append(b, byte('0'+hour/10), byte('0'+hour%10))
+	}
+	b = append(b, " "...)
+	// %l
+	{
+		h := hour
+		if h > 12 {
+			h -= 12
+		}
+		if h == 0 {
+			h = 12
+		}
+		if h < 10 {
+			b = append(b, ' ', byte('0'+h))
+		} else {
+			b = append(b, byte('0'+h/10), byte('0'+h%10))
+		}
+	}
+	b = append(b, " "...)
+	// %M
+	b = append(b, byte('0'+minute/10), byte('0'+minute%10))
+	b = append(b, " "...)
+	// %m
+	b = append(b, byte('0'+int(month)/10), byte('0'+int(month)%10))
+	b = append(b, " "...)
+	// %n
+	b = append(b, '\n')
+	b = append(b, " "...)
+	// %p
+	if hour < 12 {
+		b = append(b, 'A', 'M')
+	} else {
+		b = append(b, 'P', 'M')
+	}
+	b = append(b, " "...)
+	// %H
+	b = append(b, byte('0'+hour/10), byte('0'+hour%10))
+	b = append(b, ":"...)
+	// %M
+	b = append(b, byte('0'+minute/10), byte('0'+minute%10))
+	b = append(b, " "...)
+	// %I
+	{
+		h := hour
+		if h > 12 {
+			h -= 12
+		}
+		if h == 0 {
+			h = 12
+		}
+		b = append(b, byte('0'+h/10), byte('0'+h%10))
+	}
+	b = append(b, ":"...)
+	// %M
+	b = append(b, byte('0'+minute/10), byte('0'+minute%10))
+	b = append(b, ":"...)
+	// %S
+	b = append(b, byte('0'+second/10), byte('0'+second%10))
+	b = append(b, " "...)
+	// %p
+	if hour < 12 {
+		b = append(b, 'A', 'M')
+	} else {
+		b = append(b, 'P', 'M')
+	}
+	b = append(b, " "...)
+	// %S
+	b = append(b, byte('0'+second/10), byte('0'+second%10))
+	b = append(b, " "...)
+	// %H
+	b = append(b, byte('0'+hour/10), byte('0'+hour%10))
+	b = append(b, ":"...)
+	// %M
+	b = append(b, byte('0'+minute/10), byte('0'+minute%10))
+	b = append(b, ":"...)
+	// %S
+	b = append(b, byte('0'+second/10), byte('0'+second%10))
+	b = append(b, " "...)
+	// %t
+	b = append(b, '\t')
+	b = append(b, " "...)
+	// %U
+	{
+		n := (yday + 6 - int(wday)) / 7
+		b = append(b, byte('0'+n/10), byte('0'+n%10))
+	}
+	b = append(b, " "...)
+	// %u
+	if wday == 0 {
+		b = append(b, '7')
+	} else {
+		b = append(b, byte('0'+int(wday)))
+	}
+	b = append(b, " "...)
+	// %V
+	b = append(b, byte('0'+isoWeek/10), byte('0'+isoWeek%10))
+	b = append(b, " "...)
+	// %e
+	if day < 10 {
+		b = append(b, ' ', byte('0'+day))
+	} else {
+		b = append(b, byte('0'+day/10), byte('0'+day%10))
+	}
+	b = append(b, "-"...)
+	// %b
+	b = append(b, month.String()[:3]...)
+	b = append(b, "-"...)
+	// %Y
+	{
+		y := year
+		if y < 0 {
+			b = append(b, '-')
+			y = -y
+		}
+		switch {
+		case y < 10:
+			b = append(b, '0', '0', '0')
+		case y < 100:
+			b = append(b, '0', '0')
+		case y < 1000:
+			b = append(b, '0')
+		}
+		b = strconv.AppendInt(b, int64(y), 10)
+	}
+	b = append(b, " "...)
+	// %W
+	{
+		offset := int(wday)
+		if offset != 0 {
+			offset = 7 - offset
+		}
+		n := (yday + offset) / 7
+		b = append(b, byte('0'+n/10), byte('0'+n%10))
+	}
+	b = append(b, " "...)
+	// %w
+	b = append(b, byte('0'+int(wday)))
+	b = append(b, " "...)
+	// %H
+	b = append(b, byte('0'+hour/10), byte('0'+hour%10))
+	b = append(b, ":"...)
+	// %M
+	b = append(b, byte('0'+minute/10), byte('0'+minute%10))
+	b = append(b, ":"...)
+	// %S
+	b = append(b, byte('0'+second/10), byte('0'+second%10))
+	b = append(b, " "...)
+	// %m
+	b = append(b, byte('0'+int(month)/10), byte('0'+int(month)%10))
+	b = append(b, "/"...)
+	// %d
+	b = append(b, byte('0'+day/10), byte('0'+day%10))
+	b = append(b, "/"...)
+	// %y
+	{
+		y := year % 100
+		if y < 0 {
+			b = append(b, '-')
+			y = -y
+		}
+		b = append(b, byte('0'+y/10), byte('0'+y%10))
+	}
+	b = append(b, " "...)
+	// %Y
+	{
+		y := year
+		if y < 0 {
+			b = append(b, '-')
+			y = -y
+		}
+		switch {
+		case y < 10:
+			b = append(b, '0', '0', '0')
+		case y < 100:
+			b = append(b, '0', '0')
+		case y < 1000:
+			b = append(b, '0')
+		}
+		b = strconv.AppendInt(b, int64(y), 10)
+	}
+	b = append(b, " "...)
+	// %y
+	{
+		y := year % 100
+		if y < 0 {
+			b = append(b, '-')
+			y = -y
+		}
+		b = append(b, byte('0'+y/10), byte('0'+y%10))
+	}
+	b = append(b, " "...)
+	// %Z
+	if zoneName != "" {
+		b = append(b, zoneName...)
+	} else {
+		zone := zoneOffset / 60
+		if zone < 0 {
+			b = append(b, '-')
+			zone = -zone
+		} else {
+			b = append(b, '+')
+		}
+		b = append(b, byte('0'+zone/60/10), byte('0'+zone/60%10), byte('0'+zone%60/10), byte('0'+zone%60%10))
+	}
+	b = append(b, " "...)
+	// %z
+	{
+		zone := zoneOffset / 60
+		if zone < 0 {
+			b = append(b, '-')
+			zone = -zone
+		} else {
+			b = append(b, '+')
+		}
+		b = append(b, byte('0'+zone/60/10), byte('0'+zone/60%10), byte('0'+zone%60/10), byte('0'+zone%60%10))
+	}
+	b = append(b, " "...)
+	// %%
+	b = append(b, '%')
+	return b
+}
@@ -0,0 +1,55 @@
+// Code generated by strftime-gen -pattern "%A %a %B %b %C %c %D %d %e %F %G %g %H %h %I %j %k %l %M %m %n %p %R %r %S %T %t %U %u %V %v %W %w %X %x %Y %y %Z %z %%" -func AppendEverything -o everything_gen.go -test; DO NOT EDIT.
+
+package example
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/strftime"
+)
+
+func TestAppendEverything(t *testing.T) {
+	f, err := strftime.New("%A %a %B %b %C %c %D %d %e %F %G %g %H %h %I %j %k %l %M %m %n %p %R %r %S %T %t %U %u %V %v %W %w %X %x %Y %y %Z %z %%")
+	if err != nil {
+		t.Fatalf("failed to compile pattern: %s", err)
+	}
+
+	locations := []*time.Location{
+		time.UTC,
+		time.FixedZone("XST", -(3*3600 + 30*60)),
+		time.FixedZone("", 5*3600+45*60),
+	}
+	if loc, err := time.LoadLocation("America/New_York"); err == nil {
+		locations = append(locations, loc)
+	}
+
+	var expected, got []byte
+	check := func(tm time.Time) {
+		expected = f.FormatBuffer(expected[:0], tm)
+		got = AppendEverything(got[:0], tm)
+		if string(expected) != string(got) {
+			t.Errorf("AppendEverything(%s) = %q, want %q", tm, got, expected)
+		}
+	}
+
+	for _, loc := range locations {
+		// every day around a few new years, to cover week numbers
+		for _, year := range []int{1970, 2000, 2004, 2005, 2009, 2020, 2100} {
+			for d := -10; d <= 10; d++ {
+				check(time.Date(year, time.January, 1+d, 0, 0, 0, 0, loc))
+				check(time.Date(year, time.January, 1+d, 12, 30, 0, 0, loc))
+			}
+		}
+		// every hour of a day
+		for h := 0; h < 24; h++ {
+			check(time.Date(2020, time.March, 8, h, 5, 9, 0, loc))
+		}
+		// and then a lot of irregularly spaced times
+		tm := time.Date(1969, time.December, 25, 0, 0, 0, 0, loc)
+		for i := 0; i < 20000; i++ {
+			check(tm)
+			tm = tm.Add(97*time.Hour + 13*time.Minute + 7*time.Second)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Code generated by strftime-gen -pattern %Y-%m-%dT%H:%M:%S%z -func AppendTimestamp -o timestamp_gen.go -test; DO NOT EDIT.
+
+package example
+
+import (
+	"strconv"
+	"time"
+)
+
+// AppendTimestamp appends the textual representation of t to b using the
+// strftime pattern "%Y-%m-%dT%H:%M:%S%z", and returns the extended buffer.
+func AppendTimestamp(b []byte, t time.Time) []byte {
+	year, month, day := t.Date()
+	hour, minute, second := t.Clock()
+	_, zoneOffset := t.Zone()
+
+	// %Y
+	{
+		y := year
+		if y < 0 {
+			b = append(b, '-')
+			y = -y
+		}
+		switch {
+		case y < 10:
+			b = append(b, '0', '0', '0')
+		case y < 100:
+			b = append(b, '0', '0')
+		case y < 1000:
+			b = append(b, '0')
+		}
+		b = strconv.AppendInt(b, int64(y), 10)
+	}
+	b = append(b, "-"...)
+	// %m
+	b = append(b, byte('0'+int(month)/10), byte('0'+int(month)%10))
+	b = append(b, "-"...)
+	// %d
+	b = append(b, byte('0'+day/10), byte('0'+day%10))
+	b = append(b, "T"...)
+	// %H
+	b = append(b, byte('0'+hour/10), byte('0'+hour%10))
+	b = append(b, ":"...)
+	// %M
+	b = append(b, byte('0'+minute/10), byte('0'+minute%10))
+	b = append(b, ":"...)
+	// %S
+	b = append(b, byte('0'+second/10), byte('0'+second%10))
+	// %z
+	{
+		zone := zoneOffset / 60
+		if zone < 0 {
+			b = append(b, '-')
+			zone = -zone
+		} else {
+			b = append(b, '+')
+		}
+		b = append(b, byte('0'+zone/60/10), byte('0'+zone/60%10), byte('0'+zone%60/10), byte('0'+zone%60%10))
+	}
+	return b
+}
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/lestrrat-go/strftime"
+)
+
+type generator struct {
+	pattern  string
+	funcName string
+	pkg      string
+	cmdline  string
+}
+
+// values that are computed once at the beginning of the generated function
+const (
+	needYear = 1 << iota
+	needMonth
+	needDay
+	needHour
+	needMinute
+	needSecond
+	needWeekday
+	needYearDay
+	needISOYear
+	needISOWeek
+	needZoneName
+	needZoneOffset
+)
+
+// each entry computes one or more values. Values that are not needed
+// are assigned to the blank identifier
+var prologue = []struct {
+	names []string
+	needs []int
+	expr  string
+}{
+	{[]string{`year`, `month`, `day`}, []int{needYear, needMonth, needDay}, `t.Date()`},
+	{[]string{`hour`, `minute`, `second`}, []int{needHour, needMinute, needSecond}, `t.Clock()`},
+	{[]string{`wday`}, []int{needWeekday}, `t.Weekday()`},
+	{[]string{`yday`}, []int{needYearDay}, `t.YearDay()`},
+	{[]string{`isoYear`, `isoWeek`}, []int{needISOYear, needISOWeek}, `t.ISOWeek()`},
+	{[]string{`zoneName`, `zoneOffset`}, []int{needZoneName, needZoneOffset}, `t.Zone()`},
+}
+
+type specificationCode struct {
+	need int
+	code string
+}
+
+const twoDigits = `b = append(b, byte('0'+%[1]s/10), byte('0'+%[1]s%%10))`
+
+// appendYear mirrors the 4 digit padding done by time.Format for "2006"
+const appendYear = `{
+	y := %s
+	if y < 0 {
+		b = append(b, '-')
+		y = -y
+	}
+	switch {
+	case y < 10:
+		b = append(b, '0', '0', '0')
+	case y < 100:
+		b = append(b, '0', '0')
+	case y < 1000:
+		b = append(b, '0')
+	}
+	b = strconv.AppendInt(b, int64(y), 10)
+}`
+
+const appendTwoDigitYear = `{
+	y := %s %% 100
+	if y < 0 {
+		b = append(b, '-')
+		y = -y
+	}
+	b = append(b, byte('0'+y/10), byte('0'+y%%10))
+}`
+
+const appendZoneOffset = `{
+	zone := zoneOffset / 60
+	if zone < 0 {
+		b = append(b, '-')
+		zone = -zone
+	} else {
+		b = append(b, '+')
+	}
+	b = append(b, byte('0'+zone/60/10), byte('0'+zone/60%10), byte('0'+zone%60/10), byte('0'+zone%60%10))
+}`
+
+// the code for each of the default specifications. Each must produce
+// the exact same output as the corresponding Appender in the library
+var specificationCodes = map[byte]specificationCode{
+	'A': {needWeekday, `b = append(b, wday.String()...)`},
+	'a': {needWeekday, `b = append(b, wday.String()[:3]...)`},
+	'B': {needMonth, `b = append(b, month.String()...)`},
+	'b': {needMonth, `b = append(b, month.String()[:3]...)`},
+	'C': {needYear, `{
+	n := year / 100
+	if n < 10 {
+		b = append(b, '0')
+	}
+	b = strconv.AppendInt(b, int64(n), 10)
+}`},
+	'd': {needDay, fmt.Sprintf(twoDigits, `day`)},
+	'e': {needDay, `if day < 10 {
+	b = append(b, ' ', byte('0'+day))
+} else {
+	` + fmt.Sprintf(twoDigits, `day`) + `
+}`},
+	'G': {needISOYear, fmt.Sprintf(appendYear, `isoYear`)},
+	'g': {needISOYear, fmt.Sprintf(appendTwoDigitYear, `isoYear`)},
+	'H': {needHour, fmt.Sprintf(twoDigits, `hour`)},
+	'I': {needHour, `{
+	h := hour
+	if h > 12 {
+		h -= 12
+	}
+	if h == 0 {
+		h = 12
+	}
+	` + fmt.Sprintf(twoDigits, `h`) + `
+}`},
+	'j': {needYearDay, `b = append(b, byte('0'+yday/100), byte('0'+yday/10%10), byte('0'+yday%10))`},
+	'k': {needHour, `if hour < 10 {
+	b = append(b, ' ', byte('0'+hour))
+} else {
+	` + fmt.Sprintf(twoDigits, `hour`) + `
+}`},
+	'l': {needHour, `{
+	h := hour
+	if h > 12 {
+		h -= 12
+	}
+	if h == 0 {
+		h = 12
+	}
+	if h < 10 {
+		b = append(b, ' ', byte('0'+h))
+	} else {
+		` + fmt.Sprintf(twoDigits, `h`) + `
+	}
+}`},
+	'M': {needMinute, fmt.Sprintf(twoDigits, `minute`)},
+	'm': {needMonth, fmt.Sprintf(twoDigits, `int(month)`)},
+	'n': {0, `b = append(b, '\n')`},
+	'p': {needHour, `if hour < 12 {
+	b = append(b, 'A', 'M')
+} else {
+	b = append(b, 'P', 'M')
+}`},
+	'S': {needSecond, fmt.Sprintf(twoDigits, `second`)},
+	't': {0, `b = append(b, '\t')`},
+	'U': {needWeekday | needYearDay, `{
+	n := (yday + 6 - int(wday)) / 7
+	` + fmt.Sprintf(twoDigits, `n`) + `
+}`},
+	'u': {needWeekday, `if wday == 0 {
+	b = append(b, '7')
+} else {
+	b = append(b, byte('0'+int(wday)))
+}`},
+	'V': {needISOWeek, fmt.Sprintf(twoDigits, `isoWeek`)},
+	'W': {needWeekday | needYearDay, `{
+	offset := int(wday)
+	if offset != 0 {
+		offset = 7 - offset
+	}
+	n := (yday + offset) / 7
+	` + fmt.Sprintf(twoDigits, `n`) + `
+}`},
+	'w': {needWeekday, `b = append(b, byte('0'+int(wday)))`},
+	'Y': {needYear, fmt.Sprintf(appendYear, `year`)},
+	'y': {needYear, fmt.Sprintf(appendTwoDigitYear, `year`)},
+	'Z': {needZoneName | needZoneOffset, `if zoneName != "" {
+	b = append(b, zoneName...)
+} else ` + appendZoneOffset},
+	'z': {needZoneOffset, appendZoneOffset},
+	'%': {0, `b = append(b, '%')`},
+}
+
+// composite specifications are expanded before generating code
+var compositeSpecifications = map[byte]string{
+	'c': `%a %b %e %H:%M:%S %Y`,
+	'D': `%m/%d/%y`,
+	'F': `%Y-%m-%d`,
+	'h': `%b`,
+	'R': `%H:%M`,
+	'r': `%I:%M:%S %p`,
+	'T': `%H:%M:%S`,
+	'v': `%e-%b-%Y`,
+	'X': `%H:%M:%S`,
+	'x': `%m/%d/%y`,
+}
+
+type op struct {
+	verbatim      string
+	specification byte
+}
+
+func expand(pattern string) ([]op, error) {
+	tokens, err := strftime.Tokenize(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var ops []op
+	for _, tok := range tokens {
+		switch tok.Kind {
+		case strftime.TokenVerbatim:
+			if l := len(ops); l > 0 && ops[l-1].specification == 0 {
+				ops[l-1].verbatim += tok.Text
+			} else {
+				ops = append(ops, op{verbatim: tok.Text})
+			}
+		case strftime.TokenSpecification:
+			if tok.Flags != "" || tok.Width > 0 {
+				return nil, fmt.Errorf(`%s: flags and widths are not supported`, tok.Text)
+			}
+			if composite, ok := compositeSpecifications[tok.Specification]; ok {
+				expanded, err := expand(composite)
+				if err != nil {
+					return nil, err
+				}
+				for _, o := range expanded {
+					if l := len(ops); o.specification == 0 && l > 0 && ops[l-1].specification == 0 {
+						ops[l-1].verbatim += o.verbatim
+					} else {
+						ops = append(ops, o)
+					}
+				}
+				continue
+			}
+			if _, ok := specificationCodes[tok.Specification]; !ok {
+				return nil, fmt.Errorf(`%s: unsupported specification`, tok.Text)
+			}
+			ops = append(ops, op{specification: tok.Specification})
+		default:
+			return nil, fmt.Errorf(`%s: %s specifications are not supported`, tok.Text, tok.Kind)
+		}
+	}
+	return ops, nil
+}
+
+var sourceTemplate = template.Must(template.New("source").Parse(`// Code generated by {{ .Cmdline }}; DO NOT EDIT.
+
+package {{ .Package }}
+
+import (
+{{- if .NeedStrconv }}
+	"strconv"
+{{- end }}
+	"time"
+)
+
+// {{ .FuncName }} appends the textual representation of t to b using the
+// strftime pattern {{ printf "%q" .Pattern }}, and returns the extended buffer.
+func {{ .FuncName }}(b []byte, t time.Time) []byte {
+{{- range .Prologue }}
+	{{ . }}
+{{- end }}
+{{ range .Body }}
+	{{ . }}
+{{- end }}
+	return b
+}
+`))
+
+var testTemplate = template.Must(template.New("test").Parse(`// Code generated by {{ .Cmdline }}; DO NOT EDIT.
+
+package {{ .Package }}
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/strftime"
+)
+
+func Test{{ .FuncName }}(t *testing.T) {
+	f, err := strftime.New({{ printf "%q" .Pattern }})
+	if err != nil {
+		t.Fatalf("failed to compile pattern: %s", err)
+	}
+
+	locations := []*time.Location{
+		time.UTC,
+		time.FixedZone("XST", -(3*3600 + 30*60)),
+		time.FixedZone("", 5*3600+45*60),
+	}
+	if loc, err := time.LoadLocation("America/New_York"); err == nil {
+		locations = append(locations, loc)
+	}
+
+	var expected, got []byte
+	check := func(tm time.Time) {
+		expected = f.FormatBuffer(expected[:0], tm)
+		got = {{ .FuncName }}(got[:0], tm)
+		if string(expected) != string(got) {
+			t.Errorf("{{ .FuncName }}(%s) = %q, want %q", tm, got, expected)
+		}
+	}
+
+	for _, loc := range locations {
+		// every day around a few new years, to cover week numbers
+		for _, year := range []int{1970, 2000, 2004, 2005, 2009, 2020, 2100} {
+			for d := -10; d <= 10; d++ {
+				check(time.Date(year, time.January, 1+d, 0, 0, 0, 0, loc))
+				check(time.Date(year, time.January, 1+d, 12, 30, 0, 0, loc))
+			}
+		}
+		// every hour of a day
+		for h := 0; h < 24; h++ {
+			check(time.Date(2020, time.March, 8, h, 5, 9, 0, loc))
+		}
+		// and then a lot of irregularly spaced times
+		tm := time.Date(1969, time.December, 25, 0, 0, 0, 0, loc)
+		for i := 0; i < 20000; i++ {
+			check(tm)
+			tm = tm.Add(97*time.Hour + 13*time.Minute + 7*time.Second)
+		}
+	}
+}
+`))
+
+func (g *generator) generate() ([]byte, error) {
+	ops, err := expand(g.pattern)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to compile pattern: %w`, err)
+	}
+
+	var need int
+	var body []string
+	var needStrconv bool
+	for _, o := range ops {
+		if o.specification == 0 {
+			body = append(body, fmt.Sprintf(`b = append(b, %s...)`, strconv.Quote(o.verbatim)))
+			continue
+		}
+		sc := specificationCodes[o.specification]
+		need |= sc.need
+		body = append(body, fmt.Sprintf("// %%%c\n%s", o.specification, sc.code))
+		if strings.Contains(sc.code, `strconv.`) {
+			needStrconv = true
+		}
+	}
+
+	var prologueCode []string
+	for _, p := range prologue {
+		var used bool
+		names := make([]string, len(p.names))
+		for i, name := range p.names {
+			if need&p.needs[i] == 0 {
+				names[i] = `_`
+				continue
+			}
+			names[i] = name
+			used = true
+		}
+		if used {
+			prologueCode = append(prologueCode, strings.Join(names, ", ")+" := "+p.expr)
+		}
+	}
+
+	return g.execute(sourceTemplate, map[string]interface{}{
+		"Cmdline":     g.cmdline,
+		"Package":     g.pkg,
+		"FuncName":    g.funcName,
+		"Pattern":     g.pattern,
+		"NeedStrconv": needStrconv,
+		"Prologue":    prologueCode,
+		"Body":        body,
+	})
+}
+
+func (g *generator) generateTest() ([]byte, error) {
+	return g.execute(testTemplate, map[string]interface{}{
+		"Cmdline":  g.cmdline,
+		"Package":  g.pkg,
+		"FuncName": g.funcName,
+		"Pattern":  g.pattern,
+	})
+}
+
+func (g *generator) execute(tmpl *template.Template, vars map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return nil, fmt.Errorf(`failed to generate code: %w`, err)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf(`failed to format generated code: %w`, err)
+	}
+	return src, nil
+}
@@ -0,0 +1,87 @@
+// Command strftime-gen compiles a strftime pattern into a standalone Go
+// function, which does not go through any interface dispatch. It is meant
+// to be used for the hottest of loops, via go:generate:
+//
+//	//go:generate go run github.com/lestrrat-go/strftime/cmd/strftime-gen -pattern "%Y-%m-%dT%H:%M:%S" -func AppendTimestamp -o timestamp_gen.go -test
+//
+// The generated function has the signature
+//
+//	func AppendTimestamp(b []byte, t time.Time) []byte
+//
+// and produces the same output as (*strftime.Strftime).FormatBuffer.
+// When -test is specified, a test file that verifies this over many
+// different times is generated alongside it.
+//
+// Only the default specifications, without flags or widths, are supported.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	if err := _main(); err != nil {
+		fmt.Fprintf(os.Stderr, "strftime-gen: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func _main() error {
+	var g generator
+	var output string
+	var withTest bool
+	flag.StringVar(&g.pattern, "pattern", "", "strftime pattern to compile (required)")
+	flag.StringVar(&g.funcName, "func", "", "name of the generated function (required)")
+	flag.StringVar(&g.pkg, "package", os.Getenv("GOPACKAGE"), "package name of the generated file (default: $GOPACKAGE)")
+	flag.StringVar(&output, "o", "", "output file name (default: standard output)")
+	flag.BoolVar(&withTest, "test", false, "also generate a _test.go file (requires -o)")
+	flag.Parse()
+
+	if g.pattern == "" || g.funcName == "" {
+		flag.Usage()
+		return errors.New(`-pattern and -func are required`)
+	}
+	if g.pkg == "" {
+		return errors.New(`-package is required when not run through go:generate`)
+	}
+	if withTest && output == "" {
+		return errors.New(`-test requires -o`)
+	}
+	args := make([]string, len(os.Args)-1)
+	for i, arg := range os.Args[1:] {
+		if strings.ContainsAny(arg, " \t\n\"") {
+			arg = strconv.Quote(arg)
+		}
+		args[i] = arg
+	}
+	g.cmdline = "strftime-gen " + strings.Join(args, " ")
+
+	src, err := g.generate()
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		_, err := os.Stdout.Write(src)
+		return err
+	}
+	if err := os.WriteFile(output, src, 0644); err != nil {
+		return fmt.Errorf(`failed to write %s: %w`, output, err)
+	}
+
+	if withTest {
+		src, err := g.generateTest()
+		if err != nil {
+			return err
+		}
+		testOutput := strings.TrimSuffix(output, ".go") + "_test.go"
+		if err := os.WriteFile(testOutput, src, 0644); err != nil {
+			return fmt.Errorf(`failed to write %s: %w`, testOutput, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+// Command strftime is a small command line utility to work with
+// strftime patterns.
+//
+//	strftime format [-time RFC3339_TIME] [-utc] PATTERN
+//	strftime explain PATTERN
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/lestrrat-go/strftime"
+)
+
+func main() {
+	if err := _main(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "strftime: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `usage:
+  strftime format [-time RFC3339_TIME] [-utc] PATTERN
+  strftime explain PATTERN
+`)
+}
+
+func _main(args []string) error {
+	if len(args) < 1 {
+		usage()
+		return errors.New(`subcommand required`)
+	}
+
+	switch args[0] {
+	case "format":
+		return formatCommand(args[1:])
+	case "explain":
+		return explainCommand(args[1:])
+	case "-h", "-help", "--help", "help":
+		usage()
+		return nil
+	default:
+		usage()
+		return fmt.Errorf(`unknown subcommand %q`, args[0])
+	}
+}
+
+func formatCommand(args []string) error {
+	fs := flag.NewFlagSet("format", flag.ContinueOnError)
+	timeStr := fs.String("time", "", "time to format, in RFC3339 format (default: current time)")
+	utc := fs.Bool("utc", false, "format the time in UTC")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New(`format requires exactly one pattern`)
+	}
+
+	t := time.Now()
+	if *timeStr != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, *timeStr)
+		if err != nil {
+			return fmt.Errorf(`failed to parse -time: %w`, err)
+		}
+		t = parsed
+	}
+	if *utc {
+		t = t.UTC()
+	}
+
+	f, err := strftime.New(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stdout, f.FormatString(t))
+	return nil
+}
+
+func explainCommand(args []string) error {
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New(`explain requires exactly one pattern`)
+	}
+
+	f, err := strftime.New(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return f.Explain(os.Stdout)
+}
@@ -0,0 +1,221 @@
+package strftime
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// durationAppender is the equivalent of Appender for durations. The
+// duration is given as its absolute value in nanoseconds, as the sign is
+// rendered by the DurationFormatter itself
+type durationAppender interface {
+	appendDuration([]byte, uint64) []byte
+}
+
+type durationVerbatim string
+
+func (v durationVerbatim) appendDuration(b []byte, _ uint64) []byte {
+	return append(b, v...)
+}
+
+// durationField renders a single specification, such as %H or %3f
+type durationField struct {
+	specification byte
+	flags         string
+	width         int
+}
+
+// the specifications that are available in duration patterns. Upper case
+// specifications are components that wrap around (e.g. %M is 0-59), and
+// lower case specifications are totals (e.g. %m is the total number of
+// minutes)
+const durationSpecifications = `dhHmMsSLf%nt`
+
+func appendPadded(b []byte, v uint64, digits int) []byte {
+	start := len(b)
+	b = strconv.AppendUint(b, v, 10)
+	if n := digits - (len(b) - start); n > 0 {
+		for i := 0; i < n; i++ {
+			b = append(b, '0')
+		}
+		copy(b[start+n:], b[start:len(b)-n])
+		for i := 0; i < n; i++ {
+			b[start+i] = '0'
+		}
+	}
+	return b
+}
+
+func (v durationField) appendDuration(b []byte, d uint64) []byte {
+	const (
+		second = uint64(time.Second)
+		minute = uint64(time.Minute)
+		hour   = uint64(time.Hour)
+		day    = 24 * hour
+	)
+
+	start := len(b)
+	width := v.width
+	switch v.specification {
+	case 'd':
+		b = strconv.AppendUint(b, d/day, 10)
+	case 'h':
+		b = strconv.AppendUint(b, d/hour, 10)
+	case 'H':
+		b = appendPadded(b, d/hour%24, 2)
+	case 'm':
+		b = strconv.AppendUint(b, d/minute, 10)
+	case 'M':
+		b = appendPadded(b, d/minute%60, 2)
+	case 's':
+		b = strconv.AppendUint(b, d/second, 10)
+	case 'S':
+		b = appendPadded(b, d/second%60, 2)
+	case 'L':
+		b = appendPadded(b, d/uint64(time.Millisecond)%1000, 3)
+	case 'f':
+		// like FractionalSeconds, the width is the number of digits
+		digits := 9
+		if width > 0 && width < digits {
+			digits = width
+		}
+		ns := d % second
+		div := second / 10
+		for i := 0; i < digits; i++ {
+			b = append(b, byte('0'+(ns/div)%10))
+			div /= 10
+		}
+		width = 0
+	case 'n':
+		b = append(b, '\n')
+	case 't':
+		b = append(b, '\t')
+	case '%':
+		b = append(b, '%')
+	}
+
+	if v.flags != "" || width > 0 {
+		b = applyFlags(b, start, v.flags, width)
+	}
+	return b
+}
+
+// DurationFormatter is the equivalent of Strftime for time.Duration. It is
+// compiled from a pattern using the same syntax, including flags and
+// field width, but with the following specifications:
+//
+//	%d	total number of days
+//	%h	total number of hours
+//	%H	hours within the day (00-23)
+//	%m	total number of minutes
+//	%M	minutes within the hour (00-59)
+//	%s	total number of seconds
+//	%S	seconds within the minute (00-59)
+//	%L	milliseconds within the second (000-999)
+//	%f	fractional seconds. The width is the number of digits (default 9)
+//	%n	a newline character
+//	%t	a tab character
+//	%%	a literal %
+//
+// Negative durations are rendered with a leading '-', which is placed
+// before the first specification in the pattern.
+type DurationFormatter struct {
+	pattern  string
+	compiled []durationAppender
+	// index of the first specification, which is where the sign goes
+	signAt int
+}
+
+// NewDurationFormatter creates a new DurationFormatter object. If the
+// compilation fails, then an error is returned in the second argument.
+func NewDurationFormatter(p string) (*DurationFormatter, error) {
+	f := &DurationFormatter{
+		pattern: p,
+		signAt:  -1,
+	}
+	for pos := 0; pos < len(p); {
+		tok, err := nextToken(p, pos, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile duration format: %w", err)
+		}
+		pos = tok.End
+
+		switch tok.Kind {
+		case TokenVerbatim:
+			f.compiled = append(f.compiled, durationVerbatim(tok.Text))
+			continue
+		case TokenSpecification:
+		default:
+			return nil, fmt.Errorf(`failed to compile duration format: %s is not supported in duration patterns`, tok.Text)
+		}
+
+		if !specificationIn(tok.Specification, durationSpecifications) {
+			return nil, fmt.Errorf(`failed to compile duration format: '%%%c' is not a duration specification`, tok.Specification)
+		}
+		if f.signAt < 0 && !specificationIn(tok.Specification, `%nt`) {
+			f.signAt = len(f.compiled)
+		}
+		f.compiled = append(f.compiled, durationField{
+			specification: tok.Specification,
+			flags:         tok.Flags,
+			width:         tok.Width,
+		})
+	}
+	return f, nil
+}
+
+// FormatDuration takes the format `p` and the duration `d` to produce the
+// formatted duration. Note that this function re-compiles the pattern
+// every time it is called.
+func FormatDuration(p string, d time.Duration) (string, error) {
+	f, err := NewDurationFormatter(p)
+	if err != nil {
+		return "", err
+	}
+	return f.FormatString(d), nil
+}
+
+// Pattern returns the original pattern string
+func (f *DurationFormatter) Pattern() string {
+	return f.pattern
+}
+
+func (f *DurationFormatter) format(b []byte, d time.Duration) []byte {
+	// converting before negating works for math.MinInt64, too
+	abs := uint64(d)
+	if d < 0 {
+		abs = -abs
+	}
+	for i, w := range f.compiled {
+		if i == f.signAt && d < 0 {
+			b = append(b, '-')
+		}
+		b = w.appendDuration(b, abs)
+	}
+	return b
+}
+
+// Format takes the destination `dst` and duration `d`. It formats the
+// duration using the pre-compiled pattern, and outputs the results to `dst`
+func (f *DurationFormatter) Format(dst io.Writer, d time.Duration) error {
+	var buf [64]byte
+	if _, err := dst.Write(f.format(buf[:0], d)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// FormatBuffer is equivalent to Format, but appends the result directly to
+// supplied slice dst, returning the updated slice.
+func (f *DurationFormatter) FormatBuffer(dst []byte, d time.Duration) []byte {
+	return f.format(dst, d)
+}
+
+// FormatString takes the duration `d` and formats it, returning the
+// string containing the formated data.
+func (f *DurationFormatter) FormatString(d time.Duration) string {
+	var buf [64]byte
+	return string(f.format(buf[:0], d))
+}
@@ -0,0 +1,270 @@
+package strftime
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// explainReferenceTime is the time used to generate the example output
+// in Explain. It is Go's own reference time, plus some fractional seconds
+// so that sub-second extensions produce something meaningful
+var explainReferenceTime = time.Date(2006, time.January, 2, 15, 4, 5, 123456789, time.UTC)
+
+// descriptions for the default specifications. These should be kept in
+// sync with the table in README.md
+var specificationDescriptions = map[byte]string{
+	'A': `national representation of the full weekday name`,
+	'a': `national representation of the abbreviated weekday`,
+	'B': `national representation of the full month name`,
+	'b': `national representation of the abbreviated month name`,
+	'C': `(year / 100) as decimal number; single digits are preceded by a zero`,
+	'c': `national representation of time and date`,
+	'D': `equivalent to %m/%d/%y`,
+	'd': `day of the month as a decimal number (01-31)`,
+	'e': `the day of the month as a decimal number (1-31); single digits are preceded by a blank`,
+	'F': `equivalent to %Y-%m-%d`,
+	'G': `the ISO week year with century as a decimal number with 4 digits`,
+	'g': `the ISO week year without century as a decimal number (00-99) with 2 digits`,
+	'H': `the hour (24-hour clock) as a decimal number (00-23)`,
+	'h': `same as %b`,
+	'I': `the hour (12-hour clock) as a decimal number (01-12)`,
+	'j': `the day of the year as a decimal number (001-366)`,
+	'k': `the hour (24-hour clock) as a decimal number (0-23); single digits are preceded by a blank`,
+	'l': `the hour (12-hour clock) as a decimal number (1-12); single digits are preceded by a blank`,
+	'M': `the minute as a decimal number (00-59)`,
+	'm': `the month as a decimal number (01-12)`,
+	'n': `a newline`,
+	'p': `national representation of either "ante meridiem" (a.m.) or "post meridiem" (p.m.) as appropriate`,
+	'R': `equivalent to %H:%M`,
+	'r': `equivalent to %I:%M:%S %p`,
+	'S': `the second as a decimal number (00-60)`,
+	'T': `equivalent to %H:%M:%S`,
+	't': `a tab`,
+	'U': `the week number of the year (Sunday as the first day of the week) as a decimal number (00-53)`,
+	'u': `the weekday (Monday as the first day of the week) as a decimal number (1-7)`,
+	'V': `the week number of the year (Monday as the first day of the week) as a decimal number (01-53)`,
+	'v': `equivalent to %e-%b-%Y`,
+	'W': `the week number of the year (Monday as the first day of the week) as a decimal number (00-53)`,
+	'w': `the weekday (Sunday as the first day of the week) as a decimal number (0-6)`,
+	'X': `national representation of the time`,
+	'x': `national representation of the date`,
+	'Y': `the year with century as a decimal number`,
+	'y': `the year without century as a decimal number (00-99)`,
+	'Z': `the time zone name`,
+	'z': `the time zone offset from UTC`,
+	'%': `a '%'`,
+}
+
+// things that people tend to get wrong about some of the specifications
+var specificationNotes = map[byte]string{
+	'G': `ISO week year differs from %Y around new year; pair it with %V, not %m or %d`,
+	'g': `ISO week year differs from %y around new year; pair it with %V, not %m or %d`,
+	'V': `ISO week number; pair it with %G, as %Y gives the wrong year around new year`,
+	'U': `days before the first Sunday of the year are in week 00`,
+	'W': `days before the first Monday of the year are in week 00`,
+	'I': `12-hour clock; without %p the time is ambiguous`,
+	'l': `12-hour clock; without %p the time is ambiguous`,
+	'y': `two digit year; ambiguous across centuries`,
+	'Z': `abbreviations such as CST or IST are ambiguous, and cannot be reliably parsed back`,
+}
+
+type extensionDescription struct {
+	appender    Appender
+	description string
+}
+
+func extensionDescriptions() []extensionDescription {
+	return []extensionDescription{
+		{Milliseconds(), `the milliseconds as a zero-padded, 3 digit decimal number (000-999)`},
+		{Microseconds(), `the microseconds as a zero-padded, 6 digit decimal number (000000-999999)`},
+		{UnixSeconds(), `the number of seconds since the unix epoch`},
+		{UnixMilliseconds(), `the number of milliseconds since the unix epoch`},
+		{UnixMicroseconds(), `the number of microseconds since the unix epoch`},
+		{UnixNanoseconds(), `the number of nanoseconds since the unix epoch`},
+		{FractionalSeconds(), `the fractional seconds; the width is the number of digits (default: 9)`},
+		{ZoneName(), `the IANA time zone name, or the offset from UTC for fixed zones`},
+		{Quarter(), `the quarter of the year (1-4)`},
+		{HalfYear(), `the half of the year (1-2)`},
+		{OrdinalDay(), `the day of the month with an ordinal suffix (1st-31st)`},
+		{SpelledDay(), `the day of the month as a spelled out ordinal (first-thirty-first)`},
+		{SpelledMonth(), `the month as a spelled out ordinal (first-twelfth)`},
+	}
+}
+
+// sameAppender reports if a and b are the same appender. AppendFunc values
+// are not comparable using ==, so they are compared by their code pointer
+func sameAppender(a, b Appender) bool {
+	ta := reflect.TypeOf(a)
+	if ta != reflect.TypeOf(b) {
+		return false
+	}
+	if ta.Kind() == reflect.Func {
+		return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+	}
+	if !ta.Comparable() {
+		return false
+	}
+	return a == b
+}
+
+// describer is implemented by extensions that are configurable, and
+// therefore cannot be identified by comparing them to a single value
+type describer interface {
+	describe() string
+}
+
+func describeSpecification(c byte, a Appender) string {
+	if def, ok := defaultSpecifications[c]; ok && sameAppender(def, a) {
+		return specificationDescriptions[c]
+	}
+	if d, ok := a.(describer); ok {
+		return d.describe()
+	}
+	for _, ext := range extensionDescriptions() {
+		if sameAppender(ext.appender, a) {
+			return ext.description
+		}
+	}
+	return `custom specification`
+}
+
+type explainSegment struct {
+	label       string
+	description string
+	example     string
+	note        string
+}
+
+// explainCollector is a compileHandler that keeps the Appenders that
+// were produced for the last token
+type explainCollector struct {
+	list []Appender
+}
+
+func (c *explainCollector) handle(a Appender) {
+	c.list = append(c.list, a)
+}
+
+// explain compiles the tokens one by one, so that each segment can be
+// described along with the specification character that produced it
+func explain(tokens []Token, cfg *compileConfig, ref time.Time) ([]explainSegment, error) {
+	var st compileState
+	var c explainCollector
+	segments := make([]explainSegment, 0, len(tokens))
+	for _, tok := range tokens {
+		c.list = c.list[:0]
+		if err := compileToken(&c, tok, cfg, &st); err != nil {
+			return nil, err
+		}
+
+		var example []byte
+		for _, a := range c.list {
+			example = a.Append(example, ref)
+		}
+		// within optional sections, Appenders are not passed to the
+		// handler until the section ends
+		if len(c.list) == 0 && len(st.groups) > 0 && tok.Kind != TokenOptionalStart && tok.Name != directiveIn {
+			a, err := cfg.appenderFor(tok)
+			if err == nil {
+				if st.loc != nil {
+					a = &inLocation{loc: st.loc, appender: a}
+				}
+				example = a.Append(example, ref)
+			}
+		}
+
+		segment := explainSegment{
+			label:   tok.Text,
+			example: string(example),
+		}
+		switch tok.Kind {
+		case TokenVerbatim:
+			segment.label = fmt.Sprintf("%q", tok.Text)
+			segment.description = `verbatim text`
+		case TokenSpecification:
+			// the description is for the raw Appender, before it is wrapped
+			// for flags or locations
+			raw, err := cfg.lookup(tok.Specification)
+			if err != nil {
+				return nil, err
+			}
+			segment.description = describeSpecification(tok.Specification, raw)
+			if tok.Flags != "" || tok.Width > 0 {
+				segment.description += fmt.Sprintf(" (flags: %q, width: %d)", tok.Flags, tok.Width)
+			}
+			segment.note = specificationNotes[tok.Specification]
+		case TokenOptionalStart:
+			segment.description = `start of an optional section, which is omitted if its values are zero`
+		case TokenOptionalEnd:
+			segment.description = `end of an optional section`
+		case TokenNamed:
+			segment.description = `named specification`
+			if _, ok := cfg.named[tok.Name]; !ok && tok.Name == namedAgo {
+				segment.description = `the time relative to the current time (e.g. "3 minutes ago")`
+			}
+			if tok.Name == directiveIn {
+				if tok.Argument == "" {
+					segment.description = `render the following specifications in the location of the time`
+				} else {
+					segment.description = fmt.Sprintf(`render the following specifications in %s`, tok.Argument)
+				}
+			}
+		}
+		if st.loc != nil && (tok.Kind == TokenSpecification || (tok.Kind == TokenNamed && tok.Name != directiveIn)) {
+			segment.description += fmt.Sprintf(" (in %s)", st.loc)
+		}
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
+
+// Explain writes a human readable explanation of the pattern to `out`.
+// Each segment of the pattern is listed along with its description and
+// the output it would produce for a reference time. Notes about commonly
+// misunderstood specifications follow the list.
+//
+// The output is meant to be read by humans. Please do NOT assume the
+// output format to be fixed: it is expected to change in the future.
+func (f *Strftime) Explain(out io.Writer) error {
+	ref := explainReferenceTime
+	if f.cfg.loc != nil {
+		ref = ref.In(f.cfg.loc)
+	}
+	segments, err := explain(f.tokens, &f.cfg, ref)
+	if err != nil {
+		return fmt.Errorf("failed to explain pattern: %w", err)
+	}
+
+	fmt.Fprintf(out, "pattern:        %s\n", f.pattern)
+	fmt.Fprintf(out, "reference time: %s\n", ref.Format(time.RFC3339Nano))
+	fmt.Fprintf(out, "example:        %s\n\n", f.FormatString(ref))
+
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	for _, segment := range segments {
+		fmt.Fprintf(tw, "%s\t%s\t%q\n", segment.label, segment.description, segment.example)
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to write explanation: %w", err)
+	}
+
+	var notes []string
+	seen := make(map[string]struct{})
+	for _, segment := range segments {
+		if segment.note == "" {
+			continue
+		}
+		if _, ok := seen[segment.label]; ok {
+			continue
+		}
+		seen[segment.label] = struct{}{}
+		notes = append(notes, fmt.Sprintf("  %s: %s", segment.label, segment.note))
+	}
+	if len(notes) > 0 {
+		fmt.Fprintf(out, "\nnotes:\n%s\n", strings.Join(notes, "\n"))
+	}
+	return nil
+}
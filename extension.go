@@ -1,6 +1,7 @@
 package strftime
 
 import (
+	"fmt"
 	"strconv"
 	"time"
 )
@@ -12,50 +13,141 @@ import (
 var milliseconds Appender
 var microseconds Appender
 var unixseconds Appender
+var unixmilliseconds Appender
+var unixmicroseconds Appender
+var unixnanoseconds Appender
+var zonename Appender
+var fractionalseconds Appender
+var quarter Appender
+var halfyear Appender
 
 func init() {
-	milliseconds = AppendFunc(func(b []byte, t time.Time) []byte {
-		millisecond := int(t.Nanosecond()) / int(time.Millisecond)
-		if millisecond < 100 {
-			b = append(b, '0')
-		}
-		if millisecond < 10 {
-			b = append(b, '0')
-		}
-		return append(b, strconv.Itoa(millisecond)...)
-	})
-	microseconds = AppendFunc(func(b []byte, t time.Time) []byte {
-		microsecond := int(t.Nanosecond()) / int(time.Microsecond)
-		if microsecond < 100000 {
-			b = append(b, '0')
-		}
-		if microsecond < 10000 {
-			b = append(b, '0')
-		}
-		if microsecond < 1000 {
-			b = append(b, '0')
-		}
-		if microsecond < 100 {
-			b = append(b, '0')
-		}
-		if microsecond < 10 {
-			b = append(b, '0')
-		}
-		return append(b, strconv.Itoa(microsecond)...)
-	})
+	milliseconds = millisecondsAppender{}
+	microseconds = microsecondsAppender{}
+	fractionalseconds = fractionalSecondsAppender{}
 	unixseconds = AppendFunc(func(b []byte, t time.Time) []byte {
 		return append(b, strconv.FormatInt(t.Unix(), 10)...)
 	})
+	unixmilliseconds = AppendFunc(func(b []byte, t time.Time) []byte {
+		return strconv.AppendInt(b, t.UnixMilli(), 10)
+	})
+	unixmicroseconds = AppendFunc(func(b []byte, t time.Time) []byte {
+		return strconv.AppendInt(b, t.UnixMicro(), 10)
+	})
+	unixnanoseconds = AppendFunc(func(b []byte, t time.Time) []byte {
+		return strconv.AppendInt(b, t.UnixNano(), 10)
+	})
+	zonename = AppendFunc(appendZoneName)
+	quarter = AppendFunc(func(b []byte, t time.Time) []byte {
+		return append(b, byte('1'+(t.Month()-1)/3))
+	})
+	halfyear = AppendFunc(func(b []byte, t time.Time) []byte {
+		return append(b, byte('1'+(t.Month()-1)/6))
+	})
+}
+
+type millisecondsAppender struct{}
+
+func (millisecondsAppender) Append(b []byte, t time.Time) []byte {
+	millisecond := int(t.Nanosecond()) / int(time.Millisecond)
+	if millisecond < 100 {
+		b = append(b, '0')
+	}
+	if millisecond < 10 {
+		b = append(b, '0')
+	}
+	return append(b, strconv.Itoa(millisecond)...)
+}
+
+func (millisecondsAppender) IsZero(t time.Time) bool {
+	return t.Nanosecond() < int(time.Millisecond)
+}
+
+type microsecondsAppender struct{}
+
+func (microsecondsAppender) Append(b []byte, t time.Time) []byte {
+	microsecond := int(t.Nanosecond()) / int(time.Microsecond)
+	if microsecond < 100000 {
+		b = append(b, '0')
+	}
+	if microsecond < 10000 {
+		b = append(b, '0')
+	}
+	if microsecond < 1000 {
+		b = append(b, '0')
+	}
+	if microsecond < 100 {
+		b = append(b, '0')
+	}
+	if microsecond < 10 {
+		b = append(b, '0')
+	}
+	return append(b, strconv.Itoa(microsecond)...)
+}
+
+func (microsecondsAppender) IsZero(t time.Time) bool {
+	return t.Nanosecond() < int(time.Microsecond)
+}
+
+type fractionalSecondsAppender struct{}
+
+func (v fractionalSecondsAppender) Append(b []byte, t time.Time) []byte {
+	return v.appendWidth(b, t, 9)
+}
+
+// the width is the number of digits to render, and the rest of the digits
+// are truncated (not rounded), like GNU date's %3N
+func (fractionalSecondsAppender) appendWidth(b []byte, t time.Time, width int) []byte {
+	if width > 9 {
+		width = 9
+	}
+	ns := t.Nanosecond()
+	div := 100000000
+	for i := 0; i < width; i++ {
+		b = append(b, byte('0'+(ns/div)%10))
+		div /= 10
+	}
+	return b
+}
+
+func (fractionalSecondsAppender) IsZero(t time.Time) bool {
+	return t.Nanosecond() == 0
+}
+
+// isIANALocation reports if the location of t can be loaded by its name,
+// and gives the same offset for t. Locations created by time.FixedZone
+// (and time.Local) normally fail this test
+func isIANALocation(t time.Time) bool {
+	name := t.Location().String()
+	if name == "" || name == "Local" {
+		return false
+	}
+	loc, err := loadLocation(name)
+	if err != nil {
+		return false
+	}
+	_, offset := t.Zone()
+	_, loadedOffset := t.In(loc).Zone()
+	return offset == loadedOffset
+}
+
+func appendZoneName(b []byte, t time.Time) []byte {
+	if isIANALocation(t) {
+		return append(b, t.Location().String()...)
+	}
+	return t.AppendFormat(b, "-07:00")
 }
 
 // Milliseconds returns the Appender suitable for creating a zero-padded,
-// 3-digit millisecond textual representation.
+// 3-digit millisecond textual representation. It reports a zero value
+// when the time has no milliseconds.
 func Milliseconds() Appender {
 	return milliseconds
 }
 
 // Microsecond returns the Appender suitable for creating a zero-padded,
-// 6-digit microsecond textual representation.
+// 6-digit microsecond textual representation. It reports a zero value
+// when the time has no microseconds.
 func Microseconds() Appender {
 	return microseconds
 }
@@ -65,3 +157,201 @@ func Microseconds() Appender {
 func UnixSeconds() Appender {
 	return unixseconds
 }
+
+// UnixMilliseconds returns the Appender suitable for creating
+// unix timestamp textual representation in milliseconds.
+func UnixMilliseconds() Appender {
+	return unixmilliseconds
+}
+
+// UnixMicroseconds returns the Appender suitable for creating
+// unix timestamp textual representation in microseconds.
+func UnixMicroseconds() Appender {
+	return unixmicroseconds
+}
+
+// UnixNanoseconds returns the Appender suitable for creating
+// unix timestamp textual representation in nanoseconds. The result
+// is undefined for times that do not fit in an int64 (before 1678 or
+// after 2262), as with time.Time.UnixNano.
+func UnixNanoseconds() Appender {
+	return unixnanoseconds
+}
+
+type unixFractionalSecondsAppender struct {
+	digits int
+}
+
+func (v unixFractionalSecondsAppender) Append(b []byte, t time.Time) []byte {
+	sec := t.Unix()
+	nsec := t.Nanosecond()
+	if sec < 0 && nsec > 0 {
+		// -1.5 is represented as sec = -2, nsec = 0.5
+		b = append(b, '-')
+		b = strconv.AppendInt(b, -(sec + 1), 10)
+		nsec = int(time.Second) - nsec
+	} else {
+		b = strconv.AppendInt(b, sec, 10)
+	}
+	if v.digits <= 0 {
+		return b
+	}
+	b = append(b, '.')
+	return fractionalSecondsAppender{}.appendWidth(b, time.Unix(0, int64(nsec)), v.digits)
+}
+
+func (v unixFractionalSecondsAppender) describe() string {
+	return fmt.Sprintf(`the number of seconds since the unix epoch, with %d fractional digits`, v.digits)
+}
+
+// UnixFractionalSeconds returns the Appender suitable for creating
+// unix timestamp textual representation in seconds, with `digits`
+// fractional digits (e.g. "1700000000.123"). At most 9 digits are
+// rendered, and the rest of the digits are truncated (not rounded).
+func UnixFractionalSeconds(digits int) Appender {
+	return unixFractionalSecondsAppender{digits: digits}
+}
+
+// ZoneName returns the Appender suitable for creating the full IANA
+// time zone name of the time's location (e.g. "Asia/Tokyo"). Unlike %Z,
+// which produces abbreviations that may be ambiguous (e.g. "CST"), the
+// name can be reliably resolved using time.LoadLocation.
+//
+// For locations that do not have an IANA name, such as those created by
+// time.FixedZone, the numeric offset from UTC is used instead (e.g. "+09:00").
+func ZoneName() Appender {
+	return zonename
+}
+
+// FractionalSeconds returns the Appender suitable for creating the
+// fractional part of the seconds. By default, all 9 digits (nanoseconds)
+// are rendered. When a width is given in the pattern, it is used as the
+// number of digits, and the rest are truncated (e.g. `%3N` renders
+// milliseconds, when registered as 'N').
+//
+// It reports a zero value when the time has no fractional seconds, so it
+// can be used in optional sections: `%H:%M:%S%[.%3N%]`
+func FractionalSeconds() Appender {
+	return fractionalseconds
+}
+
+// Quarter returns the Appender suitable for creating the calendar
+// quarter of the year (1-4). Use FiscalQuarter for fiscal years that
+// do not start in January.
+func Quarter() Appender {
+	return quarter
+}
+
+// HalfYear returns the Appender suitable for creating the calendar
+// half of the year (1-2).
+func HalfYear() Appender {
+	return halfyear
+}
+
+// the unix epoch (1970-01-01T00:00:00Z) in various day counts
+const (
+	secondsPerDay          = 86400
+	julianDateUnixEpoch    = 2440587.5 * secondsPerDay
+	julianDayNumberOffset  = 2440588 * secondsPerDay
+	modifiedJulianDateUnix = 40587 * secondsPerDay
+)
+
+// dayCountAppender renders the number of days since some epoch, with
+// `precision` fractional digits. The epoch is given as the number of
+// seconds that it precedes the unix epoch. Without fractional digits, the
+// number of whole days is rounded down (floored), so that the day before
+// the epoch is -1
+type dayCountAppender struct {
+	offset      int64
+	precision   int
+	description string
+}
+
+func (v dayCountAppender) Append(b []byte, t time.Time) []byte {
+	sec := t.Unix() + v.offset
+	if v.precision <= 0 {
+		days := sec / secondsPerDay
+		if sec%secondsPerDay < 0 {
+			days--
+		}
+		return strconv.AppendInt(b, days, 10)
+	}
+
+	nsec := int64(t.Nanosecond())
+	if sec < 0 {
+		b = append(b, '-')
+		sec = -sec
+		if nsec > 0 {
+			sec--
+			nsec = int64(time.Second) - nsec
+		}
+	}
+
+	b = strconv.AppendInt(b, sec/secondsPerDay, 10)
+
+	// long division of the remainder, so that the digits are exact
+	// (truncated, not rounded) regardless of the precision
+	const den = secondsPerDay * int64(time.Second)
+	num := (sec%secondsPerDay)*int64(time.Second) + nsec
+	b = append(b, '.')
+	for i := 0; i < v.precision; i++ {
+		num *= 10
+		b = append(b, byte('0'+num/den))
+		num %= den
+	}
+	return b
+}
+
+// JulianDayNumber returns the Appender suitable for creating the Julian
+// Day Number of the calendar date in UTC (e.g. 2451545 for 2000-01-01).
+// Note that this is the day number of the date, which differs from the
+// integer part of the Julian Date before noon UTC.
+func JulianDayNumber() Appender {
+	return dayCountAppender{
+		offset:      julianDayNumberOffset,
+		description: `the Julian Day Number of the date in UTC`,
+	}
+}
+
+// JulianDate returns the Appender suitable for creating the Julian Date
+// (the number of days since noon UTC, November 24, 4714 BC in the
+// proleptic Gregorian calendar) with `precision` fractional digits.
+// Digits are truncated, not rounded.
+func JulianDate(precision int) Appender {
+	return dayCountAppender{
+		offset:      julianDateUnixEpoch,
+		precision:   precision,
+		description: `the Julian Date`,
+	}
+}
+
+// ModifiedJulianDate returns the Appender suitable for creating the
+// Modified Julian Date (the number of days since 1858-11-17T00:00:00Z)
+// with `precision` fractional digits. Digits are truncated, not rounded.
+// With a precision of 0, the number of days is rounded down.
+func ModifiedJulianDate(precision int) Appender {
+	return dayCountAppender{
+		offset:      modifiedJulianDateUnix,
+		precision:   precision,
+		description: `the Modified Julian Date`,
+	}
+}
+
+// DaysSinceEpoch returns the Appender suitable for creating the number
+// of days since the unix epoch (1970-01-01T00:00:00Z) with `precision`
+// fractional digits. Digits are truncated, not rounded. With a precision
+// of 0, the number of days is rounded down, so that times before the
+// epoch are negative (e.g. -1 for 1969-12-31T12:00:00Z).
+func DaysSinceEpoch(precision int) Appender {
+	return dayCountAppender{
+		precision:   precision,
+		description: `the number of days since the unix epoch`,
+	}
+}
+
+func (v dayCountAppender) describe() string {
+	if v.precision > 0 {
+		return fmt.Sprintf(`%s, with %d fractional digits`, v.description, v.precision)
+	}
+	return v.description
+}
@@ -0,0 +1,201 @@
+package strftime
+
+import "time"
+
+// Match is a timestamp found by FindAll. The timestamp spans
+// text[Start:End]
+type Match struct {
+	Start int
+	End   int
+	Time  time.Time
+}
+
+// starter is implemented by fieldParsers that know which bytes they can
+// start with, so that scanning can skip positions that cannot match.
+// It returns true if the parser may match an empty string, in which case
+// the bytes of the following parser must be considered as well
+type starter interface {
+	startBytes(set *[256]bool) bool
+}
+
+func (v literalParser) startBytes(set *[256]bool) bool {
+	if len(v) == 0 {
+		return true
+	}
+	set[v[0]] = true
+	return false
+}
+
+func setDigits(set *[256]bool) {
+	for c := '0'; c <= '9'; c++ {
+		set[c] = true
+	}
+}
+
+func setLetters(set *[256]bool) {
+	for c := 'A'; c <= 'Z'; c++ {
+		set[c] = true
+		set[c+'a'-'A'] = true
+	}
+}
+
+func (v numberParser) startBytes(set *[256]bool) bool {
+	setDigits(set)
+	if v.spacePad {
+		set[' '] = true
+	}
+	return false
+}
+
+func (v nameParser) startBytes(set *[256]bool) bool {
+	set[' '] = true
+	for _, name := range v.names {
+		set[name[0]] = true
+		set[name[0]|0x20] = true
+	}
+	return false
+}
+
+func (ampmParser) startBytes(set *[256]bool) bool {
+	for _, c := range `AaPp` {
+		set[c] = true
+	}
+	return false
+}
+
+func (offsetParser) startBytes(set *[256]bool) bool {
+	set['+'], set['-'], set['Z'] = true, true, true
+	return false
+}
+
+func (zoneAbbrParser) startBytes(set *[256]bool) bool {
+	setLetters(set)
+	return false
+}
+
+func (zoneNameParser) startBytes(set *[256]bool) bool {
+	setLetters(set)
+	set['+'], set['-'] = true, true
+	return false
+}
+
+func (v fractionParser) startBytes(set *[256]bool) bool {
+	setDigits(set)
+	return v.min == 0
+}
+
+func (unixParser) startBytes(set *[256]bool) bool {
+	setDigits(set)
+	set['-'] = true
+	return false
+}
+
+func (ordinalDayParser) startBytes(set *[256]bool) bool {
+	setDigits(set)
+	return false
+}
+
+func (v optionalParser) startBytes(set *[256]bool) bool {
+	for _, p := range v.list {
+		s, ok := p.(starter)
+		if !ok {
+			// be conservative, and accept any byte
+			for i := range set {
+				set[i] = true
+			}
+			return true
+		}
+		if !s.startBytes(set) {
+			break
+		}
+	}
+	return true
+}
+
+// startSet returns the set of bytes that the list of fieldParsers can
+// start with, or nil if any byte may start a match
+func startSet(list []fieldParser) *[256]bool {
+	var set [256]bool
+	for _, p := range list {
+		s, ok := p.(starter)
+		if !ok {
+			return nil
+		}
+		if !s.startBytes(&set) {
+			return &set
+		}
+	}
+	return nil
+}
+
+// matchPrefix parses the timestamp at the start of b, and returns the
+// number of bytes consumed. Unlike parsePrefix, failures are not
+// described, which makes it suitable for scanning
+func (f *Strftime) matchPrefix(b []byte) (time.Time, int, bool) {
+	parsers, err := f.parser()
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	var st parseState
+	n, ok := parseList(parsers, b, &st)
+	if !ok || n == 0 {
+		return time.Time{}, 0, false
+	}
+	t, err := st.time(f.parseLocation())
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	return t, n, true
+}
+
+func isWordChar(c byte) bool {
+	return isDigit(c) || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+}
+
+// find returns the first timestamp in text[from:]. Timestamps that start
+// or end in the middle of a word (a run of letters and digits) are skipped
+func (f *Strftime) find(text []byte, from int) (Match, bool) {
+	if _, err := f.parser(); err != nil {
+		return Match{}, false
+	}
+	set := f.parserStart
+	for i := from; i < len(text); i++ {
+		if set != nil && !set[text[i]] {
+			continue
+		}
+		if i > 0 && isWordChar(text[i-1]) && isWordChar(text[i]) {
+			continue
+		}
+		t, n, ok := f.matchPrefix(text[i:])
+		if !ok {
+			continue
+		}
+		end := i + n
+		if end < len(text) && isWordChar(text[end-1]) && isWordChar(text[end]) {
+			continue
+		}
+		return Match{Start: i, End: end, Time: t}, true
+	}
+	return Match{}, false
+}
+
+// FindAll returns the timestamps in `text` that match the pattern, along
+// with the times that they represent (see Parse). If `n` is negative, all
+// of the timestamps are returned. Otherwise, at most `n` are returned.
+//
+// Matches do not overlap, and timestamps that start or end in the middle
+// of a word (a run of letters and digits) are ignored, so that for
+// example `%Y%m%d` does not match part of a longer number. FindAll returns
+// nil if there are no matches, or if the pattern cannot be parsed.
+func (f *Strftime) FindAll(text []byte, n int) []Match {
+	var matches []Match
+	for pos := 0; pos < len(text) && (n < 0 || len(matches) < n); {
+		m, ok := f.find(text, pos)
+		if !ok {
+			break
+		}
+		matches = append(matches, m)
+		pos = m.End
+	}
+	return matches
+}
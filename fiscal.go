@@ -0,0 +1,147 @@
+package strftime
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// FiscalCalendar describes how fiscal years are laid out. The zero value
+// is a fiscal year that is the same as the calendar year.
+type FiscalCalendar struct {
+	// StartMonth is the month that the fiscal year starts in. Zero is
+	// treated as January
+	StartMonth time.Month
+	// NameByEndYear specifies that fiscal years are named after the
+	// calendar year that they end in, instead of the year that they start
+	// in. For example, with StartMonth set to October, the fiscal year
+	// starting in October 2024 is FY2025 if this is true, and FY2024 if not.
+	NameByEndYear bool
+	// Weeks445 specifies that the 4-4-5 retail calendar is used. Each
+	// quarter consists of periods of 4, 4 and 5 weeks, and the fiscal year
+	// starts on the StartWeekday nearest to the first day of StartMonth.
+	// Years that have 53 weeks add the extra week to the last period.
+	Weeks445     bool
+	StartWeekday time.Weekday
+}
+
+func (fc FiscalCalendar) startMonth() time.Month {
+	if fc.StartMonth < time.January || fc.StartMonth > time.December {
+		return time.January
+	}
+	return fc.StartMonth
+}
+
+// yearStart returns the first day of the 4-4-5 fiscal year that starts
+// in the calendar year y, as the number of days since the unix epoch
+func (fc FiscalCalendar) yearStart(y int) int64 {
+	d := time.Date(y, fc.startMonth(), 1, 0, 0, 0, 0, time.UTC)
+	diff := (int(fc.StartWeekday) - int(d.Weekday()) + 7) % 7
+	if diff > 3 {
+		diff -= 7
+	}
+	return d.Unix()/86400 + int64(diff)
+}
+
+// periodOf returns the name of the fiscal year, the quarter (1-4) and the
+// period (1-12) that t belongs to
+func (fc FiscalCalendar) periodOf(t time.Time) (int, int, int) {
+	start := fc.startMonth()
+	y, m, d := t.Date()
+
+	startYear := y
+	if m < start {
+		startYear--
+	}
+
+	var period int
+	if fc.Weeks445 {
+		// the boundaries do not follow the calendar months, so the
+		// guess above may be off by one around the start of the year
+		day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400
+		if day < fc.yearStart(startYear) {
+			startYear--
+		} else if day >= fc.yearStart(startYear+1) {
+			startYear++
+		}
+
+		week := int(day-fc.yearStart(startYear)) / 7
+		if week >= 52 {
+			period = 12
+		} else {
+			period = (week/13)*3 + 1
+			switch w := week % 13; {
+			case w >= 8:
+				period += 2
+			case w >= 4:
+				period++
+			}
+		}
+	} else {
+		period = (int(m)-int(start)+12)%12 + 1
+	}
+
+	year := startYear
+	if fc.NameByEndYear && start != time.January {
+		year++
+	}
+	return year, (period-1)/3 + 1, period
+}
+
+const (
+	fiscalFieldYear = iota
+	fiscalFieldQuarter
+	fiscalFieldPeriod
+)
+
+type fiscalAppender struct {
+	calendar FiscalCalendar
+	field    int
+}
+
+func (v fiscalAppender) Append(b []byte, t time.Time) []byte {
+	year, quarter, period := v.calendar.periodOf(t)
+	switch v.field {
+	case fiscalFieldYear:
+		return strconv.AppendInt(b, int64(year), 10)
+	case fiscalFieldQuarter:
+		return append(b, byte('0'+quarter))
+	default:
+		return unrollTwoDigits(b, period)
+	}
+}
+
+func (v fiscalAppender) describe() string {
+	var layout string
+	if v.calendar.Weeks445 {
+		layout = fmt.Sprintf(", 4-4-5 weeks starting on %s", v.calendar.StartWeekday)
+	}
+	switch v.field {
+	case fiscalFieldYear:
+		return fmt.Sprintf(`the fiscal year starting in %s%s`, v.calendar.startMonth(), layout)
+	case fiscalFieldQuarter:
+		return fmt.Sprintf(`the fiscal quarter (1-4) of the year starting in %s%s`, v.calendar.startMonth(), layout)
+	default:
+		return fmt.Sprintf(`the fiscal period (01-12) of the year starting in %s%s`, v.calendar.startMonth(), layout)
+	}
+}
+
+// FiscalYear returns the Appender suitable for creating the fiscal year
+// as a decimal number, according to the given FiscalCalendar.
+func FiscalYear(fc FiscalCalendar) Appender {
+	return fiscalAppender{calendar: fc, field: fiscalFieldYear}
+}
+
+// FiscalQuarter returns the Appender suitable for creating the quarter
+// of the fiscal year (1-4), according to the given FiscalCalendar.
+func FiscalQuarter(fc FiscalCalendar) Appender {
+	return fiscalAppender{calendar: fc, field: fiscalFieldQuarter}
+}
+
+// FiscalPeriod returns the Appender suitable for creating the period
+// of the fiscal year as a zero-padded decimal number (01-12), according
+// to the given FiscalCalendar. Periods are months, unless the 4-4-5
+// calendar is used.
+func FiscalPeriod(fc FiscalCalendar) Appender {
+	return fiscalAppender{calendar: fc, field: fiscalFieldPeriod}
+}
@@ -0,0 +1,481 @@
+package strftime
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Candidate is a pattern proposed by Infer
+type Candidate struct {
+	// Pattern is the proposed pattern
+	Pattern string
+	// Options holds the extensions that the pattern uses, which must be
+	// passed to New along with the pattern
+	Options []Option
+	// Confidence is the likelihood of the pattern relative to the rest of
+	// the candidates, between 0 and 1. The confidence of all of the
+	// candidates adds up to 1
+	Confidence float64
+}
+
+type lexemeKind int
+
+const (
+	lexDigits lexemeKind = iota
+	lexLetters
+	lexOffset
+	lexZoneName
+	lexOther
+)
+
+type lexeme struct {
+	kind lexemeKind
+	text string
+}
+
+// lexSample splits the sample into runs of digits, runs of letters and
+// other text. Offsets from UTC and IANA zone names are recognized as a
+// whole
+func lexSample(s string) []lexeme {
+	var list []lexeme
+	seenColon := false
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case (c == '+' || c == '-') && seenColon:
+			var st parseState
+			if n, ok := (offsetParser{}).parse([]byte(s[i:]), &st); ok {
+				list = append(list, lexeme{kind: lexOffset, text: s[i : i+n]})
+				i += n
+				continue
+			}
+		case isDigit(c):
+			j := i
+			for j < len(s) && isDigit(s[j]) {
+				j++
+			}
+			list = append(list, lexeme{kind: lexDigits, text: s[i:j]})
+			i = j
+			continue
+		case isLetter(c):
+			j := i
+			for j < len(s) && isLetter(s[j]) {
+				j++
+			}
+			if j < len(s) && s[j] == '/' {
+				var st parseState
+				if n, ok := (zoneNameParser{}).parse([]byte(s[i:]), &st); ok && n > j-i {
+					list = append(list, lexeme{kind: lexZoneName, text: s[i : i+n]})
+					i += n
+					continue
+				}
+			}
+			list = append(list, lexeme{kind: lexLetters, text: s[i:j]})
+			i = j
+			continue
+		}
+		if c == ':' {
+			seenColon = true
+		}
+		// merge with the preceding text
+		if n := len(list); n > 0 && list[n-1].kind == lexOther {
+			list[n-1].text += string(c)
+		} else {
+			list = append(list, lexeme{kind: lexOther, text: string(c)})
+		}
+		i++
+	}
+	return list
+}
+
+// the fields that a pattern can specify at most once
+type inferRole int
+
+const (
+	roleNone inferRole = iota
+	roleYear
+	roleMonth
+	roleDay
+	roleWeekday
+	roleHour
+	roleMinute
+	roleSecond
+	roleFraction
+	roleAMPM
+	roleZone
+	roleUnix
+)
+
+// inferAlternative is one of the interpretations of a lexeme
+type inferAlternative struct {
+	pattern string
+	roles   []inferRole
+	options []Option
+	weight  float64
+}
+
+func literalAlternative(s string) inferAlternative {
+	return inferAlternative{pattern: strings.ReplaceAll(s, `%`, `%%`), weight: 1}
+}
+
+// inferColumn holds the values of a lexeme across the samples that have
+// the same shape as the first one
+type inferColumn struct {
+	values []int
+	widths []int
+}
+
+func (c inferColumn) within(min, max int) bool {
+	for _, v := range c.values {
+		if v < min || v > max {
+			return false
+		}
+	}
+	return true
+}
+
+// padding returns the flag for numbers of 2 digits: "" if all of the
+// values are zero padded, or "-" if the widths vary
+func (c inferColumn) padding() string {
+	for _, w := range c.widths {
+		if w != 2 {
+			return `-`
+		}
+	}
+	return ``
+}
+
+func (c inferColumn) allWidth(w int) bool {
+	for _, v := range c.widths {
+		if v != w {
+			return false
+		}
+	}
+	return true
+}
+
+func isMonthName(s string) (string, bool) {
+	for _, name := range longMonthNames {
+		if strings.EqualFold(s, name) {
+			return `%B`, true
+		}
+	}
+	for _, name := range shortMonthNames {
+		if strings.EqualFold(s, name) {
+			return `%b`, true
+		}
+	}
+	return ``, false
+}
+
+func isDayName(s string) (string, bool) {
+	for _, name := range longDayNames {
+		if strings.EqualFold(s, name) {
+			return `%A`, true
+		}
+	}
+	for _, name := range shortDayNames {
+		if strings.EqualFold(s, name) {
+			return `%a`, true
+		}
+	}
+	return ``, false
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+}
+
+// isClockNumber reports if the lexeme can be a part of a time of day,
+// such as the hour in 15:04:05
+func isClockNumber(lx lexeme) bool {
+	return lx.kind == lexDigits && len(lx.text) <= 2
+}
+
+// colonPosition returns the position of the lexeme at i in a chain of
+// numbers separated by colons, such as 15:04:05, or -1 if it is not in one
+func colonPosition(lexemes []lexeme, i int) int {
+	linked := func(j int) bool {
+		return j >= 2 && j < len(lexemes) && isClockNumber(lexemes[j]) && lexemes[j-1].text == `:` && isClockNumber(lexemes[j-2])
+	}
+	if !isClockNumber(lexemes[i]) || (!linked(i) && !linked(i+2)) {
+		return -1
+	}
+	pos := 0
+	for j := i; linked(j); j -= 2 {
+		pos++
+	}
+	return pos
+}
+
+func inferAlternatives(lexemes []lexeme, columns []inferColumn, i int) []inferAlternative {
+	lx := lexemes[i]
+	col := columns[i]
+	switch lx.kind {
+	case lexOffset:
+		return []inferAlternative{{pattern: `%z`, roles: []inferRole{roleZone}, weight: 1}}
+	case lexZoneName:
+		return []inferAlternative{{pattern: `%Z`, roles: []inferRole{roleZone}, options: []Option{WithZoneName('Z')}, weight: 1}}
+	case lexLetters:
+		if spec, ok := isMonthName(lx.text); ok {
+			return []inferAlternative{{pattern: spec, roles: []inferRole{roleMonth}, weight: 1}}
+		}
+		if spec, ok := isDayName(lx.text); ok {
+			return []inferAlternative{{pattern: spec, roles: []inferRole{roleWeekday}, weight: 1}}
+		}
+		if strings.EqualFold(lx.text, `AM`) || strings.EqualFold(lx.text, `PM`) {
+			return []inferAlternative{{pattern: `%p`, roles: []inferRole{roleAMPM}, weight: 1}}
+		}
+		if lx.text == `Z` && i > 0 {
+			// %z parses "Z", but formats UTC as +0000. A literal Z in
+			// UTC formats the samples as they were
+			return []inferAlternative{
+				{pattern: `Z`, roles: []inferRole{roleZone}, options: []Option{WithUTC()}, weight: 1},
+				{pattern: `%z`, roles: []inferRole{roleZone}, weight: 0.5},
+			}
+		}
+		if len(lx.text) >= 3 && len(lx.text) <= 5 && strings.ToUpper(lx.text) == lx.text && i > 0 {
+			return []inferAlternative{
+				{pattern: `%Z`, roles: []inferRole{roleZone}, weight: 1},
+				{pattern: lx.text, weight: 0.5},
+			}
+		}
+		return []inferAlternative{literalAlternative(lx.text)}
+	case lexOther:
+		return []inferAlternative{literalAlternative(lx.text)}
+	}
+
+	// fractional seconds follow the seconds
+	if i >= 2 && (lexemes[i-1].text == `.` || lexemes[i-1].text == `,`) && colonPosition(lexemes, i-2) == 2 {
+		w := len(lx.text)
+		if !col.allWidth(w) || w > 9 {
+			return nil
+		}
+		return []inferAlternative{{
+			pattern: `%` + strconv.Itoa(w) + `N`,
+			roles:   []inferRole{roleFraction},
+			options: []Option{WithFractionalSeconds('N')},
+			weight:  1,
+		}}
+	}
+
+	if pos := colonPosition(lexemes, i); pos >= 0 {
+		pad := col.padding()
+		switch pos {
+		case 0:
+			return []inferAlternative{
+				{pattern: `%` + pad + `H`, roles: []inferRole{roleHour}, weight: 1},
+				{pattern: `%` + pad + `I`, roles: []inferRole{roleHour}, weight: 1},
+			}
+		case 1:
+			return []inferAlternative{{pattern: `%` + pad + `M`, roles: []inferRole{roleMinute}, weight: 1}}
+		case 2:
+			return []inferAlternative{{pattern: `%` + pad + `S`, roles: []inferRole{roleSecond}, weight: 1}}
+		}
+		return nil
+	}
+
+	switch len(lx.text) {
+	case 4:
+		return []inferAlternative{{pattern: `%Y`, roles: []inferRole{roleYear}, weight: 1}}
+	case 8:
+		return []inferAlternative{{pattern: `%Y%m%d`, roles: []inferRole{roleYear, roleMonth, roleDay}, weight: 1}}
+	case 14:
+		return []inferAlternative{{pattern: `%Y%m%d%H%M%S`, roles: []inferRole{roleYear, roleMonth, roleDay, roleHour, roleMinute, roleSecond}, weight: 1}}
+	case 10, 13, 16, 19:
+		if len(lexemes) > 3 {
+			return nil
+		}
+		option := map[int]Option{
+			10: WithUnixSeconds('s'),
+			13: WithUnixMilliseconds('s'),
+			16: WithUnixMicroseconds('s'),
+			19: WithUnixNanoseconds('s'),
+		}[len(lx.text)]
+		return []inferAlternative{{pattern: `%s`, roles: []inferRole{roleUnix}, options: []Option{option}, weight: 1}}
+	case 1, 2:
+	default:
+		return nil
+	}
+
+	// a number in a date. When the values do not decide the order, the
+	// month is assumed to follow a leading year, and the separator decides
+	// what the first number is (the month for `/`, the day otherwise)
+	monthWeight, dayWeight := 1.0, 1.0
+	if i >= 2 && lexemes[i-1].kind == lexOther && lexemes[i-2].kind == lexDigits {
+		if len(lexemes[i-2].text) == 4 {
+			monthWeight = 2
+		}
+	} else if i+1 < len(lexemes) && lexemes[i+1].text == `/` {
+		monthWeight = 1.5
+	} else {
+		dayWeight = 1.5
+	}
+
+	pad := col.padding()
+	var list []inferAlternative
+	if col.within(1, 12) {
+		list = append(list, inferAlternative{pattern: `%` + pad + `m`, roles: []inferRole{roleMonth}, weight: monthWeight})
+	}
+	if col.within(1, 31) {
+		list = append(list, inferAlternative{pattern: `%` + pad + `d`, roles: []inferRole{roleDay}, weight: dayWeight})
+		if pad != `` && i > 0 && strings.HasSuffix(lexemes[i-1].text, ` `) {
+			list = append(list, inferAlternative{pattern: `%e`, roles: []inferRole{roleDay}, weight: dayWeight})
+		}
+	}
+	if col.allWidth(2) {
+		list = append(list, inferAlternative{pattern: `%y`, roles: []inferRole{roleYear}, weight: 0.5})
+	}
+	return list
+}
+
+// Infer proposes patterns that match all of the samples, such as
+// `%d/%b/%Y:%H:%M:%S %z` for "02/Jan/2006:15:04:05 -0700". The candidates
+// are sorted by their confidence, in descending order.
+//
+// Day and month numbers are told apart by their values across the samples
+// (e.g. 13/01/2006 can only be a day first). When the samples are ambiguous,
+// both orders are proposed, and the one that is more common for the
+// separator is given a higher confidence (month first for `/`, day first
+// otherwise). Offsets from UTC, zone abbreviations, IANA zone names,
+// fractional seconds, and unix timestamps are also detected. Patterns that
+// use extensions come with the Options that they require.
+//
+// Every candidate is verified by parsing all of the samples with it, so the
+// more varied the samples, the fewer the candidates. An error is returned
+// if no pattern matches all of the samples.
+//
+// A trailing "Z" is proposed as a literal "Z" along with WithUTC, which
+// formats the samples as they were, and with a lower confidence as %z,
+// which also parses other offsets but formats UTC as "+0000". Candidates
+// for samples that mix "Z" with numeric offsets can only parse them.
+func Infer(samples []string) ([]Candidate, error) {
+	if len(samples) == 0 {
+		return nil, errors.New(`at least one sample must be specified`)
+	}
+
+	// the first sample gives the shape of the pattern. The rest of the
+	// samples with the same shape give the range of values
+	shape := lexSample(samples[0])
+	columns := make([]inferColumn, len(shape))
+	for _, sample := range samples {
+		lexemes := lexSample(sample)
+		if len(lexemes) != len(shape) {
+			continue
+		}
+		same := true
+		for i, lx := range lexemes {
+			if lx.kind != shape[i].kind {
+				same = false
+				break
+			}
+		}
+		if !same {
+			continue
+		}
+		for i, lx := range lexemes {
+			if lx.kind != lexDigits {
+				continue
+			}
+			v, _ := strconv.Atoi(lx.text)
+			columns[i].values = append(columns[i].values, v)
+			columns[i].widths = append(columns[i].widths, len(lx.text))
+		}
+	}
+
+	alternatives := make([][]inferAlternative, len(shape))
+	for i := range shape {
+		alternatives[i] = inferAlternatives(shape, columns, i)
+		if len(alternatives[i]) == 0 {
+			return nil, fmt.Errorf(`failed to infer the pattern: could not interpret %q`, shape[i].text)
+		}
+	}
+
+	var candidates []Candidate
+	var total float64
+	hasAMPM := false
+	for _, alts := range alternatives {
+		if len(alts) == 1 && alts[0].pattern == `%p` {
+			hasAMPM = true
+		}
+	}
+
+	seen := make(map[string]struct{})
+	var walk func(i int, p string, roles map[inferRole]bool, options []Option, weight float64)
+	walk = func(i int, p string, roles map[inferRole]bool, options []Option, weight float64) {
+		if i == len(shape) {
+			// a pattern without any specifications is not a timestamp
+			if len(roles) == 0 {
+				return
+			}
+			if _, ok := seen[p]; ok {
+				return
+			}
+			seen[p] = struct{}{}
+			if !inferMatchesAll(p, options, samples) {
+				return
+			}
+			candidates = append(candidates, Candidate{Pattern: p, Options: options, Confidence: weight})
+			total += weight
+			return
+		}
+
+	OUTER:
+		for _, alt := range alternatives[i] {
+			// the 12 hour clock goes with AM/PM, and the 24 hour clock without
+			if strings.HasSuffix(alt.pattern, `I`) != hasAMPM && (strings.HasSuffix(alt.pattern, `I`) || strings.HasSuffix(alt.pattern, `H`)) {
+				continue
+			}
+			for _, r := range alt.roles {
+				if roles[r] {
+					continue OUTER
+				}
+			}
+			next := make(map[inferRole]bool, len(roles)+len(alt.roles))
+			for r := range roles {
+				next[r] = true
+			}
+			for _, r := range alt.roles {
+				next[r] = true
+			}
+
+			prefix := p
+			if alt.pattern == `%e` && len(shape[i].text) == 1 {
+				// %e includes the padding blank
+				prefix = strings.TrimSuffix(prefix, ` `)
+			}
+			walk(i+1, prefix+alt.pattern, next, append(options[:len(options):len(options)], alt.options...), weight*alt.weight)
+		}
+	}
+	walk(0, ``, map[inferRole]bool{}, nil, 1)
+
+	if len(candidates) == 0 {
+		return nil, errors.New(`failed to infer the pattern: no pattern matches all of the samples`)
+	}
+	for i := range candidates {
+		candidates[i].Confidence /= total
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Confidence != candidates[j].Confidence {
+			return candidates[i].Confidence > candidates[j].Confidence
+		}
+		return candidates[i].Pattern < candidates[j].Pattern
+	})
+	return candidates, nil
+}
+
+func inferMatchesAll(pattern string, options []Option, samples []string) bool {
+	f, err := New(pattern, options...)
+	if err != nil {
+		return false
+	}
+	for _, sample := range samples {
+		if _, err := f.Parse(sample); err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -30,3 +30,13 @@ func TestCombine(t *testing.T) {
 		}
 	}
 }
+
+func TestUmmAlQuraMonthStarts(t *testing.T) {
+	for i := 1; i < len(ummAlQuraMonthStarts); i++ {
+		months := ummAlQuraFirstMonth + i - 1
+		days := ummAlQuraMonthStarts[i] - ummAlQuraMonthStarts[i-1]
+		if !assert.True(t, days == 29 || days == 30, `month %d of %d AH should be 29 or 30 days long, but is %d days long`, months%12+1, months/12+1, days) {
+			return
+		}
+	}
+}
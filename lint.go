@@ -0,0 +1,248 @@
+package strftime
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DiagnosticCode is a stable identifier for each kind of problem that
+// Lint reports. Codes never change meaning, so they can be used to
+// suppress specific diagnostics.
+type DiagnosticCode string
+
+const (
+	// LintInvalidPattern is reported when the pattern cannot be tokenized,
+	// for example because of a stray '%' at the end of the pattern
+	LintInvalidPattern DiagnosticCode = `SF000`
+	// LintUnknownSpecification is reported when a specification does not
+	// exist in the specification set, or when a directive such as
+	// `%{in:Location}` is invalid
+	LintUnknownSpecification DiagnosticCode = `SF001`
+	// LintMixedWeekYear is reported when ISO 8601 week date fields
+	// (%G, %g, %V) are mixed with calendar date fields (%Y, %m, %d, ...).
+	// Such patterns produce the wrong year around new year.
+	LintMixedWeekYear DiagnosticCode = `SF002`
+	// LintTwelveHourWithoutAMPM is reported when a 12-hour clock field
+	// (%I, %l) is used without %p
+	LintTwelveHourWithoutAMPM DiagnosticCode = `SF003`
+	// LintTwoDigitYear is reported when a two digit year (%y, %g, %D, %x)
+	// is used. These are ambiguous when used in persistent identifiers
+	LintTwoDigitYear DiagnosticCode = `SF004`
+	// LintMissingZone is reported when a pattern contains both a date and
+	// a time of day, but no time zone (%z, %Z). Such timestamps are
+	// ambiguous when read in a different region
+	LintMissingZone DiagnosticCode = `SF005`
+	// LintNonSortable is reported when the lexical order of the output
+	// does not match the chronological order, which is a problem when the
+	// pattern is used for file names. This happens when fields are not
+	// ordered from the most significant to the least significant, or when
+	// names, unpadded numbers, or a 12-hour clock are used.
+	LintNonSortable DiagnosticCode = `SF006`
+)
+
+// Diagnostic is a single problem found by Lint
+type Diagnostic struct {
+	Code DiagnosticCode
+	// Start and End denote the byte span of the offending part of the
+	// pattern. For problems that concern the pattern as a whole, the
+	// span covers the entire pattern
+	Start   int
+	End     int
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d-%d: %s: %s", d.Start, d.End, d.Code, d.Message)
+}
+
+type lintField int
+
+const (
+	lintFieldYear lintField = iota
+	lintFieldMonth
+	lintFieldDay
+	lintFieldHour
+	lintFieldMinute
+	lintFieldSecond
+)
+
+type lintComponent struct {
+	field lintField
+	// sortable is false if the component does not sort lexically, such
+	// as names or blank padded numbers
+	sortable bool
+}
+
+// the fields that each of the default specifications produce, in order
+var lintComponents = map[byte][]lintComponent{
+	'A': {{lintFieldDay, false}},
+	'a': {{lintFieldDay, false}},
+	'B': {{lintFieldMonth, false}},
+	'b': {{lintFieldMonth, false}},
+	'C': {{lintFieldYear, true}},
+	'c': {{lintFieldDay, false}, {lintFieldMonth, false}, {lintFieldDay, false}, {lintFieldHour, true}, {lintFieldMinute, true}, {lintFieldSecond, true}, {lintFieldYear, true}},
+	'D': {{lintFieldMonth, true}, {lintFieldDay, true}, {lintFieldYear, true}},
+	'd': {{lintFieldDay, true}},
+	'e': {{lintFieldDay, false}},
+	'F': {{lintFieldYear, true}, {lintFieldMonth, true}, {lintFieldDay, true}},
+	'G': {{lintFieldYear, true}},
+	'g': {{lintFieldYear, true}},
+	'H': {{lintFieldHour, true}},
+	'h': {{lintFieldMonth, false}},
+	'I': {{lintFieldHour, false}},
+	'j': {{lintFieldDay, true}},
+	'k': {{lintFieldHour, false}},
+	'l': {{lintFieldHour, false}},
+	'M': {{lintFieldMinute, true}},
+	'm': {{lintFieldMonth, true}},
+	'R': {{lintFieldHour, true}, {lintFieldMinute, true}},
+	'r': {{lintFieldHour, false}, {lintFieldMinute, true}, {lintFieldSecond, true}},
+	'S': {{lintFieldSecond, true}},
+	'T': {{lintFieldHour, true}, {lintFieldMinute, true}, {lintFieldSecond, true}},
+	'U': {{lintFieldMonth, true}},
+	'u': {{lintFieldDay, true}},
+	'V': {{lintFieldMonth, true}},
+	'v': {{lintFieldDay, false}, {lintFieldMonth, false}, {lintFieldYear, true}},
+	'W': {{lintFieldMonth, true}},
+	'w': {{lintFieldDay, true}},
+	'X': {{lintFieldHour, true}, {lintFieldMinute, true}, {lintFieldSecond, true}},
+	'x': {{lintFieldMonth, true}, {lintFieldDay, true}, {lintFieldYear, true}},
+	'Y': {{lintFieldYear, true}},
+	'y': {{lintFieldYear, true}},
+}
+
+func specificationIn(c byte, set string) bool {
+	return strings.IndexByte(set, c) >= 0
+}
+
+// Lint checks the pattern for problems that are not syntax errors, but
+// are likely to be bugs, such as mixing ISO 8601 week dates with calendar
+// dates. Each problem is reported as a Diagnostic with a stable code.
+//
+// Options that are accepted by New (e.g. WithSpecification) are honored
+// when checking for unknown specifications. Specific diagnostics can be
+// suppressed using WithSuppressedDiagnostics. The rest of the rules
+// assume that the default specifications are used.
+func Lint(p string, options ...Option) []Diagnostic {
+	suppressed := make(map[DiagnosticCode]struct{})
+	for _, option := range options {
+		switch option.Name() {
+		case optSuppressedDiagnostics:
+			for _, code := range option.Value().([]DiagnosticCode) {
+				suppressed[code] = struct{}{}
+			}
+		}
+	}
+
+	var diagnostics []Diagnostic
+	report := func(code DiagnosticCode, start, end int, format string, args ...interface{}) {
+		if _, ok := suppressed[code]; ok {
+			return
+		}
+		diagnostics = append(diagnostics, Diagnostic{
+			Code:    code,
+			Start:   start,
+			End:     end,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	cfg, err := newCompileConfig(options...)
+	if err != nil {
+		report(LintInvalidPattern, 0, len(p), "%s", err)
+		return diagnostics
+	}
+
+	tokens, err := cfg.tokenize(p)
+	if err != nil {
+		report(LintInvalidPattern, 0, len(p), "%s", err)
+		return diagnostics
+	}
+
+	var st compileState
+	var c explainCollector
+	var specs []Token
+	for _, tok := range tokens {
+		if tok.Kind == TokenVerbatim {
+			continue
+		}
+		c.list = c.list[:0]
+		if err := compileToken(&c, tok, &cfg, &st); err != nil {
+			report(LintUnknownSpecification, tok.Start, tok.End, "%s", err)
+			continue
+		}
+		if tok.Kind == TokenSpecification {
+			specs = append(specs, tok)
+		}
+	}
+
+	find := func(set string) (Token, bool) {
+		for _, tok := range specs {
+			if specificationIn(tok.Specification, set) {
+				return tok, true
+			}
+		}
+		return Token{}, false
+	}
+
+	// ISO week year vs calendar dates
+	if iso, ok := find(`Gg`); ok {
+		if cal, ok := find(`mdejbBhUWDFvcx`); ok {
+			report(LintMixedWeekYear, iso.Start, iso.End, "%s (ISO week year) is mixed with %s (calendar date), which gives the wrong year around new year; use %%Y instead", iso.Text, cal.Text)
+		}
+	}
+	if week, ok := find(`V`); ok {
+		if cal, ok := find(`YyCDFvcx`); ok {
+			report(LintMixedWeekYear, cal.Start, cal.End, "%s (calendar year) is mixed with %s (ISO week number), which gives the wrong year around new year; use %%G instead", cal.Text, week.Text)
+		}
+	}
+
+	if twelve, ok := find(`Il`); ok {
+		if _, ok := find(`p`); !ok {
+			report(LintTwelveHourWithoutAMPM, twelve.Start, twelve.End, "%s (12-hour clock) is used without %%p, so the time is ambiguous", twelve.Text)
+		}
+	}
+
+	for _, tok := range specs {
+		if specificationIn(tok.Specification, `ygDx`) {
+			report(LintTwoDigitYear, tok.Start, tok.End, "%s produces a two digit year, which is ambiguous in persistent identifiers", tok.Text)
+		}
+	}
+
+	if _, ok := find(`Zz`); !ok {
+		_, hasDate := find(`dejFDvcx`)
+		_, hasTime := find(`HIklRrTXc`)
+		if hasDate && hasTime {
+			report(LintMissingZone, 0, len(p), "pattern contains a date and a time but no time zone (%%z or %%Z)")
+		}
+	}
+
+	lintSortable(specs, report)
+	return diagnostics
+}
+
+func lintSortable(specs []Token, report func(DiagnosticCode, int, int, string, ...interface{})) {
+	var count int
+	for _, tok := range specs {
+		count += len(lintComponents[tok.Specification])
+	}
+	// a single field is always sortable
+	if count < 2 {
+		return
+	}
+
+	prev := lintFieldYear
+	for _, tok := range specs {
+		for _, component := range lintComponents[tok.Specification] {
+			if !component.sortable || strings.ContainsAny(tok.Flags, "-_") {
+				report(LintNonSortable, tok.Start, tok.End, "%s does not sort lexically in chronological order", tok.Text)
+				return
+			}
+			if component.field < prev {
+				report(LintNonSortable, tok.Start, tok.End, "%s appears after a less significant field, so the output does not sort in chronological order", tok.Text)
+				return
+			}
+			prev = component.field
+		}
+	}
+}
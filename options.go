@@ -1,5 +1,7 @@
 package strftime
 
+import "time"
+
 type Option interface {
 	Name() string
 	Value() interface{}
@@ -23,6 +25,24 @@ func WithSpecificationSet(ds SpecificationSet) Option {
 	}
 }
 
+const optLocation = `opt-location`
+
+// WithLocation specifies that times should be converted to the given
+// location before being formatted, so that callers do not need to
+// remember to call `t.In(loc)` themselves. Specifications that print the
+// time zone, such as %Z and %z, reflect the given location.
+func WithLocation(loc *time.Location) Option {
+	return &option{
+		name:  optLocation,
+		value: loc,
+	}
+}
+
+// WithUTC is a shorthand for WithLocation(time.UTC)
+func WithUTC() Option {
+	return WithLocation(time.UTC)
+}
+
 type optSpecificationPair struct {
 	name     byte
 	appender Appender
@@ -65,3 +85,240 @@ func WithMicroseconds(b byte) Option {
 func WithUnixSeconds(b byte) Option {
 	return WithSpecification(b, UnixSeconds())
 }
+
+// WithUnixMilliseconds is similar to WithSpecification, and specifies that
+// the Strftime object should interpret the pattern `%b` (where b
+// is the byte that you specify as the argument)
+// as the unix timestamp in milliseconds
+func WithUnixMilliseconds(b byte) Option {
+	return WithSpecification(b, UnixMilliseconds())
+}
+
+// WithUnixMicroseconds is similar to WithSpecification, and specifies that
+// the Strftime object should interpret the pattern `%b` (where b
+// is the byte that you specify as the argument)
+// as the unix timestamp in microseconds
+func WithUnixMicroseconds(b byte) Option {
+	return WithSpecification(b, UnixMicroseconds())
+}
+
+// WithUnixNanoseconds is similar to WithSpecification, and specifies that
+// the Strftime object should interpret the pattern `%b` (where b
+// is the byte that you specify as the argument)
+// as the unix timestamp in nanoseconds
+func WithUnixNanoseconds(b byte) Option {
+	return WithSpecification(b, UnixNanoseconds())
+}
+
+// WithUnixFractionalSeconds is similar to WithSpecification, and specifies that
+// the Strftime object should interpret the pattern `%b` (where b
+// is the byte that you specify as the argument)
+// as the unix timestamp in seconds, with `digits` fractional digits
+func WithUnixFractionalSeconds(b byte, digits int) Option {
+	return WithSpecification(b, UnixFractionalSeconds(digits))
+}
+
+type optNamedSpecificationPair struct {
+	name     string
+	appender Appender
+}
+
+const optNamedSpecification = `opt-named-specification`
+
+// WithNamedSpecification allows you to specify an Appender for the
+// named specification `%{name}`. Unlike WithSpecification, which is
+// limited to a single byte, named specifications can have descriptive
+// names.
+func WithNamedSpecification(name string, a Appender) Option {
+	return &option{
+		name: optNamedSpecification,
+		value: &optNamedSpecificationPair{
+			name:     name,
+			appender: a,
+		},
+	}
+}
+
+const optSuppressedDiagnostics = `opt-suppressed-diagnostics`
+
+// WithSuppressedDiagnostics specifies the diagnostic codes that Lint
+// should not report.
+func WithSuppressedDiagnostics(codes ...DiagnosticCode) Option {
+	return &option{
+		name:  optSuppressedDiagnostics,
+		value: codes,
+	}
+}
+
+// WithZoneName is similar to WithSpecification, and specifies that
+// the Strftime object should interpret the pattern `%b` (where b
+// is the byte that you specify as the argument)
+// as the IANA time zone name of the time's location.
+func WithZoneName(b byte) Option {
+	return WithSpecification(b, ZoneName())
+}
+
+// WithFractionalSeconds is similar to WithSpecification, and specifies that
+// the Strftime object should interpret the pattern `%b` (where b
+// is the byte that you specify as the argument)
+// as the fractional part of the seconds. The width in the pattern
+// specifies the number of digits (e.g. `%3b` for milliseconds)
+func WithFractionalSeconds(b byte) Option {
+	return WithSpecification(b, FractionalSeconds())
+}
+
+const optCase = `opt-case`
+
+// WithCase specifies a rule for Select, and the Formatter to use when
+// the rule matches.
+func WithCase(rule SelectRule, f Formatter) Option {
+	return &option{
+		name: optCase,
+		value: &selectCase{
+			rule:      rule,
+			formatter: f,
+		},
+	}
+}
+
+const optClock = `opt-clock`
+
+// WithClock specifies the Clock that is used to determine the current
+// time, for formatters that render times relative to the current time.
+func WithClock(c Clock) Option {
+	return &option{
+		name:  optClock,
+		value: c,
+	}
+}
+
+const optRelativeTimeThresholds = `opt-relative-time-thresholds`
+
+// WithRelativeTimeThresholds specifies the thresholds that `%{ago}` uses
+// to choose the unit of the relative time.
+func WithRelativeTimeThresholds(thresholds RelativeTimeThresholds) Option {
+	return &option{
+		name:  optRelativeTimeThresholds,
+		value: thresholds,
+	}
+}
+
+// WithQuarter is similar to WithSpecification, and specifies that
+// the Strftime object should interpret the pattern `%b` (where b
+// is the byte that you specify as the argument)
+// as the calendar quarter of the year.
+func WithQuarter(b byte) Option {
+	return WithSpecification(b, Quarter())
+}
+
+// WithHalfYear is similar to WithSpecification, and specifies that
+// the Strftime object should interpret the pattern `%b` (where b
+// is the byte that you specify as the argument)
+// as the calendar half of the year.
+func WithHalfYear(b byte) Option {
+	return WithSpecification(b, HalfYear())
+}
+
+// WithFiscalYear is similar to WithSpecification, and specifies that
+// the Strftime object should interpret the pattern `%b` (where b
+// is the byte that you specify as the argument)
+// as the fiscal year, according to the given FiscalCalendar.
+func WithFiscalYear(b byte, fc FiscalCalendar) Option {
+	return WithSpecification(b, FiscalYear(fc))
+}
+
+// WithFiscalQuarter is similar to WithSpecification, and specifies that
+// the Strftime object should interpret the pattern `%b` (where b
+// is the byte that you specify as the argument)
+// as the quarter of the fiscal year, according to the given FiscalCalendar.
+func WithFiscalQuarter(b byte, fc FiscalCalendar) Option {
+	return WithSpecification(b, FiscalQuarter(fc))
+}
+
+// WithFiscalPeriod is similar to WithSpecification, and specifies that
+// the Strftime object should interpret the pattern `%b` (where b
+// is the byte that you specify as the argument)
+// as the period of the fiscal year, according to the given FiscalCalendar.
+func WithFiscalPeriod(b byte, fc FiscalCalendar) Option {
+	return WithSpecification(b, FiscalPeriod(fc))
+}
+
+// WithOrdinalDay is similar to WithSpecification, and specifies that
+// the Strftime object should interpret the pattern `%b` (where b
+// is the byte that you specify as the argument)
+// as the day of the month with an ordinal suffix (e.g. "3rd").
+func WithOrdinalDay(b byte) Option {
+	return WithSpecification(b, OrdinalDay())
+}
+
+// WithSpelledDay is similar to WithSpecification, and specifies that
+// the Strftime object should interpret the pattern `%b` (where b
+// is the byte that you specify as the argument)
+// as the day of the month spelled out as an ordinal (e.g. "twenty-first").
+func WithSpelledDay(b byte) Option {
+	return WithSpecification(b, SpelledDay())
+}
+
+// WithSpelledMonth is similar to WithSpecification, and specifies that
+// the Strftime object should interpret the pattern `%b` (where b
+// is the byte that you specify as the argument)
+// as the month number spelled out as an ordinal (e.g. "third").
+func WithSpelledMonth(b byte) Option {
+	return WithSpecification(b, SpelledMonth())
+}
+
+// WithJulianDayNumber is similar to WithSpecification, and specifies that
+// the Strftime object should interpret the pattern `%b` (where b
+// is the byte that you specify as the argument)
+// as the Julian Day Number of the date in UTC.
+func WithJulianDayNumber(b byte) Option {
+	return WithSpecification(b, JulianDayNumber())
+}
+
+// WithJulianDate is similar to WithSpecification, and specifies that
+// the Strftime object should interpret the pattern `%b` (where b
+// is the byte that you specify as the argument)
+// as the Julian Date, with `precision` fractional digits.
+func WithJulianDate(b byte, precision int) Option {
+	return WithSpecification(b, JulianDate(precision))
+}
+
+// WithModifiedJulianDate is similar to WithSpecification, and specifies that
+// the Strftime object should interpret the pattern `%b` (where b
+// is the byte that you specify as the argument)
+// as the Modified Julian Date, with `precision` fractional digits.
+func WithModifiedJulianDate(b byte, precision int) Option {
+	return WithSpecification(b, ModifiedJulianDate(precision))
+}
+
+// WithDaysSinceEpoch is similar to WithSpecification, and specifies that
+// the Strftime object should interpret the pattern `%b` (where b
+// is the byte that you specify as the argument)
+// as the number of days since the unix epoch, with `precision`
+// fractional digits.
+func WithDaysSinceEpoch(b byte, precision int) Option {
+	return WithSpecification(b, DaysSinceEpoch(precision))
+}
+
+const optCalendar = `opt-calendar`
+
+// WithCalendar specifies the Calendar that the date specifications
+// (%Y, %y, %m, %d, %e, %B, %j, %F, %D and %x) are rendered in. It also
+// enables the `%{era}` named specification.
+func WithCalendar(c Calendar) Option {
+	return &option{
+		name:  optCalendar,
+		value: c,
+	}
+}
+
+const optRewriteMode = `opt-rewrite-mode`
+
+// WithRewriteMode specifies where the Rewriter looks for timestamps. By
+// default, only the timestamps at the start of each line are rewritten.
+func WithRewriteMode(mode RewriteMode) Option {
+	return &option{
+		name:  optRewriteMode,
+		value: mode,
+	}
+}
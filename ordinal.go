@@ -0,0 +1,85 @@
+package strftime
+
+import (
+	"strconv"
+	"time"
+)
+
+var ordinalday Appender
+var spelledday Appender
+var spelledmonth Appender
+
+func init() {
+	ordinalday = AppendFunc(func(b []byte, t time.Time) []byte {
+		d := t.Day()
+		b = strconv.AppendInt(b, int64(d), 10)
+		return append(b, ordinalSuffix(d)...)
+	})
+	spelledday = AppendFunc(func(b []byte, t time.Time) []byte {
+		return appendSpelledOrdinal(b, t.Day())
+	})
+	spelledmonth = AppendFunc(func(b []byte, t time.Time) []byte {
+		return appendSpelledOrdinal(b, int(t.Month()))
+	})
+}
+
+// ordinalSuffix returns the English ordinal suffix for n
+func ordinalSuffix(n int) string {
+	if n%100 >= 11 && n%100 <= 13 {
+		return "th"
+	}
+	switch n % 10 {
+	case 1:
+		return "st"
+	case 2:
+		return "nd"
+	case 3:
+		return "rd"
+	default:
+		return "th"
+	}
+}
+
+var spelledOrdinals = [...]string{
+	"", "first", "second", "third", "fourth", "fifth", "sixth", "seventh",
+	"eighth", "ninth", "tenth", "eleventh", "twelfth", "thirteenth",
+	"fourteenth", "fifteenth", "sixteenth", "seventeenth", "eighteenth",
+	"nineteenth", "twentieth",
+}
+
+// appendSpelledOrdinal appends the English ordinal for 1 <= n <= 39, which
+// covers days of the month and months
+func appendSpelledOrdinal(b []byte, n int) []byte {
+	if n < len(spelledOrdinals) {
+		return append(b, spelledOrdinals[n]...)
+	}
+	switch n / 10 {
+	case 2:
+		b = append(b, "twenty"...)
+	case 3:
+		if n == 30 {
+			return append(b, "thirtieth"...)
+		}
+		b = append(b, "thirty"...)
+	}
+	b = append(b, '-')
+	return append(b, spelledOrdinals[n%10]...)
+}
+
+// OrdinalDay returns the Appender suitable for creating the day of the
+// month followed by its English ordinal suffix (e.g. "1st", "22nd", "3rd").
+func OrdinalDay() Appender {
+	return ordinalday
+}
+
+// SpelledDay returns the Appender suitable for creating the day of the
+// month as a spelled out English ordinal (e.g. "first", "twenty-first").
+func SpelledDay() Appender {
+	return spelledday
+}
+
+// SpelledMonth returns the Appender suitable for creating the month
+// number as a spelled out English ordinal (e.g. "third" for March).
+func SpelledMonth() Appender {
+	return spelledmonth
+}
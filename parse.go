@@ -0,0 +1,738 @@
+package strftime
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// parseState accumulates the fields that were parsed, until they are
+// converted to a time.Time
+type parseState struct {
+	year          int
+	yearNoCentury int
+	century       int
+	month         int
+	day           int
+	yday          int
+	hour          int
+	minute        int
+	second        int
+	nsec          int
+	offset        int
+	zoneAbbr      string
+	loc           *time.Location
+
+	hasYear          bool
+	hasYearNoCentury bool
+	hasCentury       bool
+	hasYday          bool
+	hasAMPM          bool
+	pm               bool
+	hasOffset        bool
+
+	unix     bool
+	unixSec  int64
+	unixNsec int64
+}
+
+type parseField int
+
+const (
+	parseIgnore parseField = iota
+	parseYear
+	parseYearNoCentury
+	parseCentury
+	parseMonth
+	parseDay
+	parseYday
+	parseHour
+	parseHour12
+	parseMinute
+	parseSecond
+)
+
+func (st *parseState) set(field parseField, v int) {
+	switch field {
+	case parseYear:
+		st.year, st.hasYear = v, true
+	case parseYearNoCentury:
+		st.yearNoCentury, st.hasYearNoCentury = v, true
+	case parseCentury:
+		st.century, st.hasCentury = v, true
+	case parseMonth:
+		st.month = v
+	case parseDay:
+		st.day = v
+	case parseYday:
+		st.yday, st.hasYday = v, true
+	case parseHour, parseHour12:
+		st.hour = v
+	case parseMinute:
+		st.minute = v
+	case parseSecond:
+		st.second = v
+	}
+}
+
+// fieldParser parses a single element of a pattern at the start of s. It
+// returns the number of bytes consumed, or false if s does not match
+type fieldParser interface {
+	parse(s []byte, st *parseState) (int, bool)
+}
+
+type literalParser string
+
+func (v literalParser) parse(s []byte, _ *parseState) (int, bool) {
+	if len(s) < len(v) || string(s[:len(v)]) != string(v) {
+		return 0, false
+	}
+	return len(v), true
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// numberParser parses a decimal number of up to `max` digits. If
+// `spacePad` is true, leading blanks count towards the digits
+type numberParser struct {
+	field    parseField
+	min      int
+	max      int
+	spacePad bool
+}
+
+func (v numberParser) parse(s []byte, st *parseState) (int, bool) {
+	i := 0
+	if v.spacePad {
+		for i < len(s) && i < v.max-1 && s[i] == ' ' {
+			i++
+		}
+	}
+	start := i
+	n := 0
+	for i < len(s) && i < v.max && isDigit(s[i]) {
+		n = n*10 + int(s[i]-'0')
+		i++
+	}
+	digits := i - start
+	if digits == 0 || (!v.spacePad && digits < v.min) || (v.spacePad && i < v.min) {
+		return 0, false
+	}
+	st.set(v.field, n)
+	return i, true
+}
+
+// nameParser parses one of the names, case insensitively. The value that
+// is set is the 1-based index of the name
+type nameParser struct {
+	field parseField
+	names []string
+}
+
+func (v nameParser) parse(s []byte, st *parseState) (int, bool) {
+	i := 0
+	for i < len(s) && s[i] == ' ' {
+		i++
+	}
+	// the longest name that matches wins, e.g. "June" over "Jun"
+	best := -1
+	for n, name := range v.names {
+		if len(s)-i >= len(name) && strings.EqualFold(string(s[i:i+len(name)]), name) {
+			if best < 0 || len(name) > len(v.names[best]) {
+				best = n
+			}
+		}
+	}
+	if best < 0 {
+		return 0, false
+	}
+	st.set(v.field, best+1)
+	return i + len(v.names[best]), true
+}
+
+var (
+	longMonthNames  []string
+	shortMonthNames []string
+	longDayNames    []string
+	shortDayNames   []string
+)
+
+func init() {
+	for m := time.January; m <= time.December; m++ {
+		longMonthNames = append(longMonthNames, m.String())
+		shortMonthNames = append(shortMonthNames, m.String()[:3])
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		longDayNames = append(longDayNames, d.String())
+		shortDayNames = append(shortDayNames, d.String()[:3])
+	}
+}
+
+type ampmParser struct{}
+
+func (ampmParser) parse(s []byte, st *parseState) (int, bool) {
+	if len(s) < 2 || (s[1] != 'M' && s[1] != 'm') {
+		return 0, false
+	}
+	switch s[0] {
+	case 'A', 'a':
+		st.pm = false
+	case 'P', 'p':
+		st.pm = true
+	default:
+		return 0, false
+	}
+	st.hasAMPM = true
+	return 2, true
+}
+
+// offsetParser parses the offset from UTC, as in +0900, +09:00 or Z
+type offsetParser struct{}
+
+func (offsetParser) parse(s []byte, st *parseState) (int, bool) {
+	if len(s) > 0 && s[0] == 'Z' {
+		st.offset, st.hasOffset = 0, true
+		return 1, true
+	}
+	if len(s) < 5 || (s[0] != '+' && s[0] != '-') {
+		return 0, false
+	}
+	i := 3
+	if s[i] == ':' {
+		i++
+	}
+	if len(s) < i+2 || !isDigit(s[1]) || !isDigit(s[2]) || !isDigit(s[i]) || !isDigit(s[i+1]) {
+		return 0, false
+	}
+	hh := int(s[1]-'0')*10 + int(s[2]-'0')
+	mm := int(s[i]-'0')*10 + int(s[i+1]-'0')
+	if mm > 59 {
+		return 0, false
+	}
+	st.offset = (hh*60 + mm) * 60
+	if s[0] == '-' {
+		st.offset = -st.offset
+	}
+	st.hasOffset = true
+	return i + 2, true
+}
+
+// zoneAbbrParser parses time zone abbreviations, such as JST or UTC
+type zoneAbbrParser struct{}
+
+func (zoneAbbrParser) parse(s []byte, st *parseState) (int, bool) {
+	i := 0
+	for i < len(s) && ((s[i] >= 'A' && s[i] <= 'Z') || (s[i] >= 'a' && s[i] <= 'z')) {
+		i++
+	}
+	if i < 2 {
+		return 0, false
+	}
+	st.zoneAbbr = string(s[:i])
+	return i, true
+}
+
+// zoneNameParser parses the output of ZoneName
+type zoneNameParser struct{}
+
+func isZoneNameChar(c byte) bool {
+	return (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || isDigit(c) || c == '/' || c == '_' || c == '-' || c == '+'
+}
+
+func (zoneNameParser) parse(s []byte, st *parseState) (int, bool) {
+	// fixed zones are rendered as offsets
+	if len(s) > 0 && (s[0] == '+' || s[0] == '-') {
+		return offsetParser{}.parse(s, st)
+	}
+	i := 0
+	for i < len(s) && isZoneNameChar(s[i]) {
+		i++
+	}
+	if i == 0 {
+		return 0, false
+	}
+	loc, err := loadLocation(string(s[:i]))
+	if err != nil {
+		return 0, false
+	}
+	st.loc = loc
+	return i, true
+}
+
+// fractionParser parses fractional seconds of `min` to `max` digits
+type fractionParser struct {
+	min int
+	max int
+}
+
+func (v fractionParser) parse(s []byte, st *parseState) (int, bool) {
+	i := 0
+	nsec := 0
+	for i < len(s) && i < v.max && isDigit(s[i]) {
+		nsec = nsec*10 + int(s[i]-'0')
+		i++
+	}
+	if i < v.min {
+		return 0, false
+	}
+	for j := i; j < 9; j++ {
+		nsec *= 10
+	}
+	st.nsec = nsec
+	return i, true
+}
+
+// unixParser parses a signed number of `unit` since the unix epoch. If
+// `digits` is positive, the number is followed by that many fractional
+// digits
+type unixParser struct {
+	unit   time.Duration
+	digits int
+}
+
+func (v unixParser) parse(s []byte, st *parseState) (int, bool) {
+	i := 0
+	neg := false
+	if len(s) > 0 && s[0] == '-' {
+		neg = true
+		i++
+	}
+	start := i
+	var n int64
+	for i < len(s) && isDigit(s[i]) {
+		d := int64(s[i] - '0')
+		// values that do not fit in an int64 are rejected
+		if n > (math.MaxInt64-d)/10 {
+			return 0, false
+		}
+		n = n*10 + d
+		i++
+	}
+	if i == start {
+		return 0, false
+	}
+
+	var nsec int64
+	if v.digits > 0 {
+		if i >= len(s) || s[i] != '.' {
+			return 0, false
+		}
+		var fst parseState
+		l, ok := fractionParser{min: v.digits, max: v.digits}.parse(s[i+1:], &fst)
+		if !ok {
+			return 0, false
+		}
+		i += 1 + l
+		nsec = int64(fst.nsec)
+	}
+
+	sec := n / int64(time.Second/v.unit)
+	nsec += (n % int64(time.Second/v.unit)) * int64(v.unit)
+	if neg {
+		sec = -sec
+		nsec = -nsec
+	}
+	st.unix = true
+	st.unixSec = sec
+	st.unixNsec = nsec
+	return i, true
+}
+
+// ordinalDayParser parses the output of OrdinalDay
+type ordinalDayParser struct{}
+
+func (ordinalDayParser) parse(s []byte, st *parseState) (int, bool) {
+	n, ok := numberParser{field: parseDay, min: 1, max: 2}.parse(s, st)
+	if !ok || len(s) < n+2 || !strings.EqualFold(string(s[n:n+2]), ordinalSuffix(st.day)) {
+		return 0, false
+	}
+	return n + 2, true
+}
+
+// optionalParser parses `%[...%]`. If the content does not match, the
+// section is assumed to have been omitted
+type optionalParser struct {
+	list []fieldParser
+}
+
+func (v optionalParser) parse(s []byte, st *parseState) (int, bool) {
+	saved := *st
+	n, ok := parseList(v.list, s, st)
+	if !ok {
+		*st = saved
+		return 0, true
+	}
+	return n, true
+}
+
+func parseList(list []fieldParser, s []byte, st *parseState) (int, bool) {
+	pos := 0
+	for _, p := range list {
+		n, ok := p.parse(s[pos:], st)
+		if !ok {
+			return pos, false
+		}
+		pos += n
+	}
+	return pos, true
+}
+
+// the default composite specifications, expanded for parsing
+var compositeExpansions = map[byte]string{
+	'c': `%a %b %e %H:%M:%S %Y`,
+	'D': `%m/%d/%y`,
+	'F': `%Y-%m-%d`,
+	'R': `%H:%M`,
+	'r': `%I:%M:%S %p`,
+	'T': `%H:%M:%S`,
+	'v': `%e-%b-%Y`,
+	'X': `%H:%M:%S`,
+	'x': `%m/%d/%y`,
+}
+
+// numeric default specifications: the field, the number of digits, and
+// whether the number is padded with blanks
+var numericSpecifications = map[byte]struct {
+	field    parseField
+	digits   int
+	spacePad bool
+}{
+	'C': {parseCentury, 2, false},
+	'd': {parseDay, 2, false},
+	'e': {parseDay, 2, true},
+	'G': {parseIgnore, 4, false},
+	'g': {parseIgnore, 2, false},
+	'H': {parseHour, 2, false},
+	'I': {parseHour12, 2, false},
+	'j': {parseYday, 3, false},
+	'k': {parseHour, 2, true},
+	'l': {parseHour12, 2, true},
+	'M': {parseMinute, 2, false},
+	'm': {parseMonth, 2, false},
+	'S': {parseSecond, 2, false},
+	'U': {parseIgnore, 2, false},
+	'u': {parseIgnore, 1, false},
+	'V': {parseIgnore, 2, false},
+	'W': {parseIgnore, 2, false},
+	'w': {parseIgnore, 1, false},
+	'Y': {parseYear, 4, false},
+	'y': {parseYearNoCentury, 2, false},
+}
+
+func defaultFieldParser(tok Token) ([]fieldParser, error) {
+	c := tok.Specification
+	if expansion, ok := compositeExpansions[c]; ok {
+		return compileParser(expansion, &compileConfig{ds: defaultSpecificationSet})
+	}
+
+	if spec, ok := numericSpecifications[c]; ok {
+		p := numberParser{field: spec.field, min: spec.digits, max: spec.digits, spacePad: spec.spacePad}
+		if tok.Width > 0 {
+			p.min, p.max = tok.Width, tok.Width
+		}
+		switch {
+		case strings.ContainsRune(tok.Flags, '-'):
+			p.min, p.spacePad = 1, false
+		case strings.ContainsRune(tok.Flags, '_'):
+			p.spacePad = true
+		case strings.ContainsRune(tok.Flags, '0'):
+			p.spacePad = false
+		}
+		return []fieldParser{p}, nil
+	}
+
+	switch c {
+	case 'A':
+		return []fieldParser{nameParser{field: parseIgnore, names: longDayNames}}, nil
+	case 'a':
+		return []fieldParser{nameParser{field: parseIgnore, names: shortDayNames}}, nil
+	case 'B':
+		return []fieldParser{nameParser{field: parseMonth, names: longMonthNames}}, nil
+	case 'b', 'h':
+		return []fieldParser{nameParser{field: parseMonth, names: shortMonthNames}}, nil
+	case 'p':
+		return []fieldParser{ampmParser{}}, nil
+	case 'Z':
+		return []fieldParser{zoneAbbrParser{}}, nil
+	case 'z':
+		return []fieldParser{offsetParser{}}, nil
+	case 'n':
+		return []fieldParser{literalParser("\n")}, nil
+	case 't':
+		return []fieldParser{literalParser("\t")}, nil
+	case '%':
+		return []fieldParser{literalParser("%")}, nil
+	}
+	return nil, fmt.Errorf(`parsing %s is not supported`, tok.Text)
+}
+
+func extensionFieldParser(tok Token, a Appender) (fieldParser, error) {
+	switch a := a.(type) {
+	case millisecondsAppender:
+		return fractionParser{min: 3, max: 3}, nil
+	case microsecondsAppender:
+		return fractionParser{min: 6, max: 6}, nil
+	case fractionalSecondsAppender:
+		digits := 9
+		if tok.Width > 0 && tok.Width < digits {
+			digits = tok.Width
+		}
+		return fractionParser{min: digits, max: digits}, nil
+	case unixFractionalSecondsAppender:
+		digits := a.digits
+		if digits > 9 {
+			digits = 9
+		}
+		return unixParser{unit: time.Second, digits: digits}, nil
+	}
+
+	switch {
+	case sameAppender(a, unixseconds):
+		return unixParser{unit: time.Second}, nil
+	case sameAppender(a, unixmilliseconds):
+		return unixParser{unit: time.Millisecond}, nil
+	case sameAppender(a, unixmicroseconds):
+		return unixParser{unit: time.Microsecond}, nil
+	case sameAppender(a, unixnanoseconds):
+		return unixParser{unit: time.Nanosecond}, nil
+	case sameAppender(a, zonename):
+		return zoneNameParser{}, nil
+	case sameAppender(a, ordinalday):
+		return ordinalDayParser{}, nil
+	}
+	return nil, fmt.Errorf(`parsing %s is not supported`, tok.Text)
+}
+
+// compileParser creates the list of fieldParsers for the pattern. Only
+// the default specifications and the extensions whose output can be
+// parsed back are supported
+func compileParser(p string, cfg *compileConfig) ([]fieldParser, error) {
+	tokens, err := cfg.tokenize(p)
+	if err != nil {
+		return nil, err
+	}
+	return compileTokenParser(tokens, cfg)
+}
+
+// compileTokenParser creates the list of fieldParsers for tokens that
+// have already been tokenized
+func compileTokenParser(tokens []Token, cfg *compileConfig) ([]fieldParser, error) {
+	var list []fieldParser
+	var groups [][]fieldParser
+	for _, tok := range tokens {
+		var parsers []fieldParser
+		switch tok.Kind {
+		case TokenVerbatim:
+			parsers = []fieldParser{literalParser(tok.Text)}
+		case TokenSpecification:
+			a, err := cfg.lookup(tok.Specification)
+			if err != nil {
+				return nil, err
+			}
+			if def, ok := defaultSpecifications[tok.Specification]; ok && sameAppender(def, a) {
+				parsers, err = defaultFieldParser(tok)
+			} else {
+				var fp fieldParser
+				fp, err = extensionFieldParser(tok, a)
+				parsers = []fieldParser{fp}
+			}
+			if err != nil {
+				return nil, err
+			}
+		case TokenOptionalStart:
+			groups = append(groups, list)
+			list = nil
+			continue
+		case TokenOptionalEnd:
+			if len(groups) == 0 {
+				return nil, fmt.Errorf(`%s without a matching %%[ at offset %d`, tok.Text, tok.Start)
+			}
+			parsers = []fieldParser{optionalParser{list: list}}
+			list = groups[len(groups)-1]
+			groups = groups[:len(groups)-1]
+		default:
+			return nil, fmt.Errorf(`parsing %s is not supported`, tok.Text)
+		}
+		list = append(list, parsers...)
+	}
+	if len(groups) > 0 {
+		return nil, errors.New(`unterminated optional section`)
+	}
+	return list, nil
+}
+
+// time converts the parsed fields to a time.Time. Times without zone
+// information are in `loc`
+func (st *parseState) time(loc *time.Location) (time.Time, error) {
+	if st.unix {
+		t := time.Unix(st.unixSec, st.unixNsec)
+		switch {
+		case st.loc != nil:
+			return t.In(st.loc), nil
+		case st.hasOffset:
+			return t.In(time.FixedZone(st.zoneAbbr, st.offset)), nil
+		}
+		return t.In(loc), nil
+	}
+
+	year := st.year
+	switch {
+	case st.hasYear:
+	case st.hasYearNoCentury && st.hasCentury:
+		year = st.century*100 + st.yearNoCentury
+	case st.hasYearNoCentury:
+		// as in POSIX strptime
+		year = 1900 + st.yearNoCentury
+		if st.yearNoCentury < 69 {
+			year += 100
+		}
+	case st.hasCentury:
+		year = st.century * 100
+	}
+
+	hour := st.hour
+	if st.hasAMPM {
+		if hour < 1 || hour > 12 {
+			return time.Time{}, errors.New(`hour out of range`)
+		}
+		hour %= 12
+		if st.pm {
+			hour += 12
+		}
+	}
+	if hour > 23 {
+		return time.Time{}, errors.New(`hour out of range`)
+	}
+	if st.minute > 59 {
+		return time.Time{}, errors.New(`minute out of range`)
+	}
+	if st.second > 59 {
+		return time.Time{}, errors.New(`second out of range`)
+	}
+
+	month, day := st.month, st.day
+	if st.hasYday && month == 0 && day == 0 {
+		if st.yday < 1 || st.yday > 366 {
+			return time.Time{}, errors.New(`day of year out of range`)
+		}
+		d := time.Date(year, time.January, st.yday, 0, 0, 0, 0, time.UTC)
+		if d.Year() != year {
+			return time.Time{}, errors.New(`day of year out of range`)
+		}
+		month, day = int(d.Month()), d.Day()
+	}
+	if month == 0 {
+		month = 1
+	}
+	if day == 0 {
+		day = 1
+	}
+	if month > 12 {
+		return time.Time{}, errors.New(`month out of range`)
+	}
+	// time.Date normalizes dates such as February 30th, which are errors
+	check := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if check.Day() != day {
+		return time.Time{}, errors.New(`day out of range`)
+	}
+
+	switch {
+	case st.loc != nil:
+		loc = st.loc
+	case st.hasOffset:
+		// use the default location if it has the same offset, so that
+		// the zone abbreviation is available
+		t := time.Date(year, time.Month(month), day, hour, st.minute, st.second, st.nsec, loc)
+		if _, offset := t.Zone(); offset != st.offset || (st.zoneAbbr != "" && t.Location().String() != st.zoneAbbr && zoneAbbr(t) != st.zoneAbbr) {
+			loc = time.FixedZone(st.zoneAbbr, st.offset)
+		}
+	case st.zoneAbbr != "":
+		switch {
+		case st.zoneAbbr == "UTC" || st.zoneAbbr == "GMT":
+			loc = time.UTC
+		case zoneAbbr(time.Date(year, time.Month(month), day, hour, st.minute, st.second, st.nsec, loc)) == st.zoneAbbr:
+		default:
+			// as in time.Parse, an unknown abbreviation is assumed to be
+			// a zone with zero offset
+			loc = time.FixedZone(st.zoneAbbr, 0)
+		}
+	}
+	return time.Date(year, time.Month(month), day, hour, st.minute, st.second, st.nsec, loc), nil
+}
+
+func zoneAbbr(t time.Time) string {
+	name, _ := t.Zone()
+	return name
+}
+
+// parser returns the fieldParsers for the pattern, compiling them on
+// first use
+func (f *Strftime) parser() ([]fieldParser, error) {
+	f.parserOnce.Do(func() {
+		f.parsers, f.parserErr = compileTokenParser(f.tokens, &f.cfg)
+		f.parserStart = startSet(f.parsers)
+	})
+	return f.parsers, f.parserErr
+}
+
+func (f *Strftime) parseLocation() *time.Location {
+	if f.cfg.loc != nil {
+		return f.cfg.loc
+	}
+	return time.UTC
+}
+
+// parsePrefix parses the time at the start of b, and returns the number
+// of bytes consumed
+func (f *Strftime) parsePrefix(b []byte) (time.Time, int, error) {
+	parsers, err := f.parser()
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	var st parseState
+	n, ok := parseList(parsers, b, &st)
+	if !ok {
+		return time.Time{}, 0, fmt.Errorf(`%q does not match pattern %q at offset %d`, b, f.pattern, n)
+	}
+	t, err := st.time(f.parseLocation())
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf(`failed to parse %q: %w`, b[:n], err)
+	}
+	return t, n, nil
+}
+
+// Parse parses the string according to the pattern, and returns the time
+// that it represents. This is the reverse of FormatString.
+//
+// Parsing is supported for the default specifications and for extensions
+// whose output can be parsed back, such as Milliseconds, UnixSeconds and
+// ZoneName. Week numbers and weekday names are accepted but ignored, and
+// two digit years are interpreted as in POSIX strptime (69-99 are 1969-1999,
+// and 00-68 are 2000-2068).
+//
+// Times without zone information are in the location given by
+// WithLocation, or UTC.
+func (f *Strftime) Parse(s string) (time.Time, error) {
+	t, n, err := f.parsePrefix([]byte(s))
+	if err != nil {
+		return time.Time{}, fmt.Errorf(`failed to parse time: %w`, err)
+	}
+	if n != len(s) {
+		return time.Time{}, fmt.Errorf(`failed to parse time: extra text %q after the time`, s[n:])
+	}
+	return t, nil
+}
+
+// Parse takes the pattern `p` and parses the string `s` according to it.
+// Note that this function re-compiles the pattern every time it is called.
+func Parse(p, s string, options ...Option) (time.Time, error) {
+	f, err := New(p, options...)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return f.Parse(s)
+}
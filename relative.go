@@ -0,0 +1,109 @@
+package strftime
+
+import (
+	"strconv"
+	"time"
+)
+
+// namedAgo is the name of the default named specification `%{ago}`,
+// which renders the time relative to the current time
+const namedAgo = `ago`
+
+// RelativeTimeThresholds controls the unit that RelativeTime uses to
+// render the difference between the time and the current time. The
+// smallest unit whose threshold is larger than the difference is used,
+// and differences that exceed all of the thresholds are rendered in years.
+type RelativeTimeThresholds struct {
+	// differences smaller than this are rendered as "just now".
+	// Zero disables it
+	JustNow time.Duration
+	Seconds time.Duration
+	Minutes time.Duration
+	Hours   time.Duration
+	Days    time.Duration
+	Months  time.Duration
+}
+
+// DefaultRelativeTimeThresholds returns the thresholds used by `%{ago}`,
+// unless they are changed using WithRelativeTimeThresholds.
+func DefaultRelativeTimeThresholds() RelativeTimeThresholds {
+	return RelativeTimeThresholds{
+		JustNow: 10 * time.Second,
+		Seconds: time.Minute,
+		Minutes: time.Hour,
+		Hours:   24 * time.Hour,
+		Days:    30 * 24 * time.Hour,
+		Months:  365 * 24 * time.Hour,
+	}
+}
+
+type relativeTimeAppender struct {
+	clock      Clock
+	thresholds RelativeTimeThresholds
+}
+
+// RelativeTime returns the Appender suitable for creating a humanized
+// representation of the time, relative to the current time as reported
+// by `c` (e.g. "3 minutes ago", "in 2 days"). If `c` is nil, time.Now
+// is used.
+//
+// Months and years are approximated as 30 and 365 days. The wording is
+// in English.
+//
+// The same Appender is available as `%{ago}` by default, using the Clock
+// given by WithClock, and the thresholds given by WithRelativeTimeThresholds.
+func RelativeTime(c Clock, thresholds RelativeTimeThresholds) Appender {
+	if c == nil {
+		c = systemClock{}
+	}
+	return relativeTimeAppender{
+		clock:      c,
+		thresholds: thresholds,
+	}
+}
+
+func (v relativeTimeAppender) Append(b []byte, t time.Time) []byte {
+	diff := v.clock.Now().Sub(t)
+	future := diff < 0
+	if future {
+		diff = -diff
+	}
+	if diff < v.thresholds.JustNow {
+		return append(b, "just now"...)
+	}
+
+	var unit time.Duration
+	var name string
+	switch {
+	case diff < v.thresholds.Seconds:
+		unit, name = time.Second, "second"
+	case diff < v.thresholds.Minutes:
+		unit, name = time.Minute, "minute"
+	case diff < v.thresholds.Hours:
+		unit, name = time.Hour, "hour"
+	case diff < v.thresholds.Days:
+		unit, name = 24*time.Hour, "day"
+	case diff < v.thresholds.Months:
+		unit, name = 30*24*time.Hour, "month"
+	default:
+		unit, name = 365*24*time.Hour, "year"
+	}
+
+	n := int64(diff / unit)
+	if n < 1 {
+		n = 1
+	}
+	if future {
+		b = append(b, "in "...)
+	}
+	b = strconv.AppendInt(b, n, 10)
+	b = append(b, ' ')
+	b = append(b, name...)
+	if n > 1 {
+		b = append(b, 's')
+	}
+	if !future {
+		b = append(b, " ago"...)
+	}
+	return b
+}
@@ -0,0 +1,130 @@
+package strftime
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// RewriteMode specifies where the Rewriter looks for timestamps
+type RewriteMode int
+
+const (
+	// RewriteLineStart only rewrites timestamps at the start of each line,
+	// which is the common layout for log files
+	RewriteLineStart RewriteMode = iota
+	// RewriteAnywhere rewrites every timestamp in the line. Timestamps must
+	// not be immediately preceded or followed by a letter or a digit
+	RewriteAnywhere
+)
+
+// Rewriter is an io.Reader that rewrites the timestamps in the underlying
+// reader from one pattern to another, line by line. Text that does not
+// match the source pattern is passed through unchanged.
+type Rewriter struct {
+	src  *bufio.Reader
+	from *Strftime
+	to   *Strftime
+	mode RewriteMode
+	loc  *time.Location
+
+	// the rewritten text that has not been read yet
+	buf []byte
+	pos int
+	err error
+	// holds lines that are longer than the buffer of src. It is reused
+	// across lines
+	long []byte
+}
+
+// NewRewriter creates a new Rewriter that reads from `r`, and rewrites
+// the timestamps that match `from` using `to`. Use WithRewriteMode to
+// specify where timestamps are looked for, and WithLocation to convert
+// the parsed times to a location before they are formatted.
+//
+// An error is returned if `from` cannot be parsed (see Parse).
+func NewRewriter(r io.Reader, from, to *Strftime, options ...Option) (*Rewriter, error) {
+	if from == nil || to == nil {
+		return nil, errors.New(`both the source and the target patterns must be specified`)
+	}
+	if _, err := from.parser(); err != nil {
+		return nil, fmt.Errorf(`failed to compile source pattern: %w`, err)
+	}
+
+	rw := &Rewriter{
+		src:  bufio.NewReader(r),
+		from: from,
+		to:   to,
+	}
+	for _, option := range options {
+		switch option.Name() {
+		case optRewriteMode:
+			rw.mode = option.Value().(RewriteMode)
+		case optLocation:
+			rw.loc = option.Value().(*time.Location)
+		}
+	}
+	return rw, nil
+}
+
+// Read implements io.Reader
+func (rw *Rewriter) Read(p []byte) (int, error) {
+	for rw.pos >= len(rw.buf) {
+		if rw.err != nil {
+			return 0, rw.err
+		}
+		rw.fill()
+	}
+	n := copy(p, rw.buf[rw.pos:])
+	rw.pos += n
+	return n, nil
+}
+
+// fill reads the next line, and rewrites it into rw.buf
+func (rw *Rewriter) fill() {
+	line, err := rw.src.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		// the line is longer than the buffer. Keep reading until the
+		// end of the line, so that timestamps are not split
+		rw.long = append(rw.long[:0], line...)
+		for errors.Is(err, bufio.ErrBufferFull) {
+			line, err = rw.src.ReadSlice('\n')
+			rw.long = append(rw.long, line...)
+		}
+		line = rw.long
+	}
+	rw.buf = rw.rewriteLine(rw.buf[:0], line)
+	rw.pos = 0
+	rw.err = err
+}
+
+func (rw *Rewriter) appendTime(b []byte, t time.Time) []byte {
+	if rw.loc != nil {
+		t = t.In(rw.loc)
+	}
+	return rw.to.format(b, t)
+}
+
+func (rw *Rewriter) rewriteLine(b, line []byte) []byte {
+	if rw.mode == RewriteLineStart {
+		if t, n, ok := rw.from.matchPrefix(line); ok {
+			b = rw.appendTime(b, t)
+			line = line[n:]
+		}
+		return append(b, line...)
+	}
+
+	start := 0
+	for start < len(line) {
+		m, ok := rw.from.find(line, start)
+		if !ok {
+			break
+		}
+		b = append(b, line[start:m.Start]...)
+		b = rw.appendTime(b, m.Time)
+		start = m.End
+	}
+	return append(b, line[start:]...)
+}
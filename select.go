@@ -0,0 +1,154 @@
+package strftime
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// Formatter is the interface implemented by objects that format times,
+// such as *Strftime and *Select
+type Formatter interface {
+	Format(io.Writer, time.Time) error
+	FormatBuffer([]byte, time.Time) []byte
+	FormatString(time.Time) string
+}
+
+// Clock is the source of the current time for formatters that render
+// times relative to the current time. It can be replaced using WithClock,
+// for example to make tests deterministic.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc is an utility type to allow users to create a
+// function-only version of a Clock
+type ClockFunc func() time.Time
+
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SelectRule decides if a time should be formatted using the Formatter
+// associated with the rule. `now` is the current time, as reported by
+// the Clock.
+type SelectRule interface {
+	Match(t, now time.Time) bool
+}
+
+// SelectRuleFunc is an utility type to allow users to create a
+// function-only version of a SelectRule
+type SelectRuleFunc func(t, now time.Time) bool
+
+func (f SelectRuleFunc) Match(t, now time.Time) bool {
+	return f(t, now)
+}
+
+// SameDay returns a SelectRule that matches times that are on the same
+// date as the current time, in the location of the time being formatted
+func SameDay() SelectRule {
+	return SelectRuleFunc(func(t, now time.Time) bool {
+		y1, m1, d1 := t.Date()
+		y2, m2, d2 := now.In(t.Location()).Date()
+		return y1 == y2 && m1 == m2 && d1 == d2
+	})
+}
+
+// SameYear returns a SelectRule that matches times that are in the same
+// year as the current time, in the location of the time being formatted
+func SameYear() SelectRule {
+	return SelectRuleFunc(func(t, now time.Time) bool {
+		return t.Year() == now.In(t.Location()).Year()
+	})
+}
+
+// Within returns a SelectRule that matches times that are within `d`
+// of the current time, either in the past or in the future
+func Within(d time.Duration) SelectRule {
+	return SelectRuleFunc(func(t, now time.Time) bool {
+		diff := now.Sub(t)
+		if diff < 0 {
+			diff = -diff
+		}
+		return diff <= d
+	})
+}
+
+type selectCase struct {
+	rule      SelectRule
+	formatter Formatter
+}
+
+// Select is a Formatter that chooses among several Formatters, depending
+// on the time being formatted. For example, a dashboard may show only the
+// time for today's events, and the full date for older ones.
+//
+// Rules are evaluated in the order that they were given, and the first
+// Formatter whose rule matches is used. If none of them match, the
+// fallback Formatter is used.
+type Select struct {
+	cases    []selectCase
+	fallback Formatter
+	clock    Clock
+}
+
+// NewSelect creates a new Select object. Use WithCase to specify the
+// rules and their Formatters, and WithClock to specify the source of the
+// current time that the rules are evaluated against.
+func NewSelect(fallback Formatter, options ...Option) (*Select, error) {
+	if fallback == nil {
+		return nil, errors.New(`fallback formatter must be specified`)
+	}
+
+	s := &Select{
+		fallback: fallback,
+		clock:    systemClock{},
+	}
+	for _, option := range options {
+		switch option.Name() {
+		case optCase:
+			c := option.Value().(*selectCase)
+			if c.rule == nil || c.formatter == nil {
+				return nil, errors.New(`both the rule and the formatter must be specified for a case`)
+			}
+			s.cases = append(s.cases, *c)
+		case optClock:
+			s.clock = option.Value().(Clock)
+		}
+	}
+	return s, nil
+}
+
+func (s *Select) choose(t time.Time) Formatter {
+	now := s.clock.Now()
+	for _, c := range s.cases {
+		if c.rule.Match(t, now) {
+			return c.formatter
+		}
+	}
+	return s.fallback
+}
+
+// Format formats the time using the Formatter chosen for `t`, and writes
+// the result to `dst`
+func (s *Select) Format(dst io.Writer, t time.Time) error {
+	return s.choose(t).Format(dst, t)
+}
+
+// FormatBuffer formats the time using the Formatter chosen for `t`, and
+// appends the result to `dst`
+func (s *Select) FormatBuffer(dst []byte, t time.Time) []byte {
+	return s.choose(t).FormatBuffer(dst, t)
+}
+
+// FormatString formats the time using the Formatter chosen for `t`, and
+// returns the result
+func (s *Select) FormatString(t time.Time) string {
+	return s.choose(t).FormatString(t)
+}
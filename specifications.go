@@ -3,33 +3,60 @@ package strftime
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
 )
 
-// because there is no such thing was a sync.RWLocker
-type rwLocker interface {
-	RLock()
-	RUnlock()
-	sync.Locker
-}
-
 // SpecificationSet is a container for patterns that Strftime uses.
 // If you want a custom strftime, you can copy the default
-// SpecificationSet and tweak it
+// SpecificationSet and tweak it.
+//
+// The sets created by this package are safe for concurrent use. Lookups
+// do not take any locks, and changes made by Set, Delete and
+// MergeSpecificationSet are observed atomically.
+//
+// See CloneSpecificationSet, SpecificationSetKeys, RangeSpecificationSet,
+// MergeSpecificationSet and FreezeSpecificationSet for operations on
+// whole sets.
 type SpecificationSet interface {
 	Lookup(byte) (Appender, error)
 	Delete(byte) error
 	Set(byte, Appender) error
 }
 
+// MergePolicy decides how MergeSpecificationSet handles characters that
+// are specified in both sets, with different Appenders
+type MergePolicy int
+
+const (
+	// MergeKeepExisting keeps the specifications that are already in
+	// the set
+	MergeKeepExisting MergePolicy = iota
+	// MergeOverwrite replaces the specifications in the set with those
+	// in the other set
+	MergeOverwrite
+	// MergeFailOnConflict makes Merge fail without changing the set
+	MergeFailOnConflict
+)
+
 type specificationSet struct {
-	mutable bool
-	lock    rwLocker
-	store   map[byte]Appender
+	frozen atomic.Bool
+	// lock serializes the writers. Readers load the store without locking
+	lock sync.Mutex
+	// store holds the current specifications. A stored map is never
+	// modified: writers store a modified copy instead, so that readers
+	// always see a consistent snapshot without locking
+	store atomic.Pointer[map[byte]Appender]
 }
 
-// The default specification set does not need any locking as it is never
-// accessed from the outside, and is never mutated.
+func newSpecificationSet(store map[byte]Appender) *specificationSet {
+	ds := &specificationSet{}
+	ds.store.Store(&store)
+	return ds
+}
+
+// The default specification set is frozen, and is never mutated.
 var defaultSpecificationSet SpecificationSet
 
 func init() {
@@ -37,34 +64,25 @@ func init() {
 }
 
 func newImmutableSpecificationSet() SpecificationSet {
-	// Create a mutable one so that populateDefaultSpecifications work through
-	// its magic, then copy the associated map
-	// (NOTE: this is done this way because there used to be
-	// two struct types for specification set, united under an interface.
-	// it can now be removed, but we would need to change the entire
-	// populateDefaultSpecifications method, and I'm currently too lazy
-	// PRs welcome)
-	tmp := NewSpecificationSet()
-
-	ss := &specificationSet{
-		mutable: false,
-		lock:    nil, // never used, so intentionally not initialized
-		store:   tmp.(*specificationSet).store,
+	store := make(map[byte]Appender, len(defaultSpecifications))
+	for c, a := range defaultSpecifications {
+		store[c] = a
 	}
-
-	return ss
+	ds := newSpecificationSet(store)
+	ds.frozen.Store(true)
+	return ds
 }
 
 // NewSpecificationSet creates a specification set with the default specifications.
 func NewSpecificationSet() SpecificationSet {
-	ds := &specificationSet{
-		mutable: true,
-		lock:    &sync.RWMutex{},
-		store:   make(map[byte]Appender),
-	}
-	populateDefaultSpecifications(ds)
+	return defaultSpecificationSet.(*specificationSet).clone()
+}
 
-	return ds
+// DefaultSpecificationSet returns the immutable set of the default
+// specifications. Use CloneSpecificationSet to derive a set that can be
+// modified.
+func DefaultSpecificationSet() SpecificationSet {
+	return defaultSpecificationSet
 }
 
 var defaultSpecifications = map[byte]Appender{
@@ -110,44 +128,304 @@ var defaultSpecifications = map[byte]Appender{
 	'%': percent,
 }
 
-func populateDefaultSpecifications(ds SpecificationSet) {
-	for c, handler := range defaultSpecifications {
-		if err := ds.Set(c, handler); err != nil {
-			panic(fmt.Sprintf("failed to set default specification for %c: %s", c, err))
-		}
-	}
-}
-
 func (ds *specificationSet) Lookup(b byte) (Appender, error) {
-	if ds.mutable {
-		ds.lock.RLock()
-		defer ds.lock.RLock()
-	}
-	v, ok := ds.store[b]
+	v, ok := ds.snapshot()[b]
 	if !ok {
 		return nil, fmt.Errorf(`lookup failed: '%%%c' was not found in specification set`, b)
 	}
 	return v, nil
 }
 
-func (ds *specificationSet) Delete(b byte) error {
-	if !ds.mutable {
-		return errors.New(`delete failed: this specification set is marked immutable`)
+// snapshot returns the current specifications. The map must not be
+// modified
+func (ds *specificationSet) snapshot() map[byte]Appender {
+	return *ds.store.Load()
+}
+
+// update calls f with a copy of the current specifications, and stores
+// the copy if f succeeds
+func (ds *specificationSet) update(op string, f func(map[byte]Appender) error) error {
+	if ds.frozen.Load() {
+		return fmt.Errorf(`%s failed: this specification set is marked immutable`, op)
 	}
 
 	ds.lock.Lock()
 	defer ds.lock.Unlock()
-	delete(ds.store, b)
+	// the set may have been frozen while waiting for the lock
+	if ds.frozen.Load() {
+		return fmt.Errorf(`%s failed: this specification set is marked immutable`, op)
+	}
+
+	current := ds.snapshot()
+	store := make(map[byte]Appender, len(current)+1)
+	for c, a := range current {
+		store[c] = a
+	}
+	if err := f(store); err != nil {
+		return err
+	}
+	ds.store.Store(&store)
 	return nil
 }
 
+func (ds *specificationSet) Delete(b byte) error {
+	return ds.update(`delete`, func(store map[byte]Appender) error {
+		delete(store, b)
+		return nil
+	})
+}
+
 func (ds *specificationSet) Set(b byte, a Appender) error {
-	if !ds.mutable {
-		return errors.New(`set failed: this specification set is marked immutable`)
+	return ds.update(`set`, func(store map[byte]Appender) error {
+		store[b] = a
+		return nil
+	})
+}
+
+// clone shares the current snapshot with the new set, as neither of them
+// modifies it
+func (ds *specificationSet) clone() *specificationSet {
+	return newSpecificationSet(ds.snapshot())
+}
+
+func sortedKeys(store map[byte]Appender) []byte {
+	keys := make([]byte, 0, len(store))
+	for c := range store {
+		keys = append(keys, c)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// Range iterates over a snapshot of the set, so f may modify the set
+func (ds *specificationSet) Range(f func(byte, Appender) bool) {
+	store := ds.snapshot()
+	for _, c := range sortedKeys(store) {
+		if !f(c, store[c]) {
+			return
+		}
+	}
+}
+
+// merge applies the specifications in incoming to store, following the
+// policy. On conflicts with MergeFailOnConflict, store is left untouched
+func merge(store, incoming map[byte]Appender, policy MergePolicy) error {
+	if policy == MergeFailOnConflict {
+		for _, c := range sortedKeys(incoming) {
+			if existing, ok := store[c]; ok && !sameAppender(existing, incoming[c]) {
+				return fmt.Errorf(`merge failed: '%%%c' is specified in both sets`, c)
+			}
+		}
+	}
+	for c, a := range incoming {
+		if _, ok := store[c]; ok && policy == MergeKeepExisting {
+			continue
+		}
+		store[c] = a
 	}
+	return nil
+}
 
+func (ds *specificationSet) freeze() {
+	if ds.frozen.Load() {
+		return
+	}
+	// wait for the writers to finish, so that the store is no longer
+	// modified once frozen
 	ds.lock.Lock()
 	defer ds.lock.Unlock()
-	ds.store[b] = a
+	ds.frozen.Store(true)
+}
+
+// specificationFreezer is implemented by sets that can be made immutable
+type specificationFreezer interface {
+	Freeze()
+}
+
+// FreezeSpecificationSet makes ds immutable, so that it can be shared
+// without being changed by its users. Freezing a set twice is harmless.
+//
+// Sets that are not created by this package are frozen using their
+// `Freeze()` method. If they do not have one, an error is returned.
+func FreezeSpecificationSet(ds SpecificationSet) error {
+	switch raw := ds.(type) {
+	case *specificationSet:
+		raw.freeze()
+		return nil
+	case *overlaySpecificationSet:
+		return nil
+	case specificationFreezer:
+		raw.Freeze()
+		return nil
+	}
+	return fmt.Errorf(`freeze failed: %T cannot be frozen`, ds)
+}
+
+// isFrozen reports if the set is known to be immutable. Sets that are not
+// created by this package are never known to be immutable
+func isFrozen(ds SpecificationSet) bool {
+	switch ds := ds.(type) {
+	case *specificationSet:
+		return ds.frozen.Load()
+	case *overlaySpecificationSet:
+		return true
+	}
+	return false
+}
+
+// overlaySpecificationSet is an immutable set that adds a few
+// specifications to an immutable base set, without copying the base set
+type overlaySpecificationSet struct {
+	base  SpecificationSet
+	extra map[byte]Appender
+}
+
+// NewOverlaySpecificationSet creates an immutable SpecificationSet that
+// looks up the given specifications first, and then those in `base`.
+// This is cheaper than cloning `base` when only a few specifications are
+// added to a frozen set (see FreezeSpecificationSet). If `base` is not
+// frozen, the overlay takes a snapshot of it instead, so that the overlay
+// does not change when `base` is modified afterwards.
+func NewOverlaySpecificationSet(base SpecificationSet, extra map[byte]Appender) SpecificationSet {
+	if !isFrozen(base) {
+		snapshot := newSpecificationSet(collectSpecifications(base))
+		snapshot.frozen.Store(true)
+		base = snapshot
+	}
+	store := make(map[byte]Appender, len(extra))
+	for c, a := range extra {
+		store[c] = a
+	}
+	return newOverlaySpecificationSet(base, store)
+}
+
+func newOverlaySpecificationSet(base SpecificationSet, extra map[byte]Appender) *overlaySpecificationSet {
+	return &overlaySpecificationSet{
+		base:  base,
+		extra: extra,
+	}
+}
+
+func (ds *overlaySpecificationSet) Lookup(b byte) (Appender, error) {
+	if v, ok := ds.extra[b]; ok {
+		return v, nil
+	}
+	return ds.base.Lookup(b)
+}
+
+func (ds *overlaySpecificationSet) Delete(byte) error {
+	return errors.New(`delete failed: this specification set is marked immutable`)
+}
+
+func (ds *overlaySpecificationSet) Set(byte, Appender) error {
+	return errors.New(`set failed: this specification set is marked immutable`)
+}
+
+func (ds *overlaySpecificationSet) snapshot() map[byte]Appender {
+	store := make(map[byte]Appender)
+	for c, a := range collectSpecifications(ds.base) {
+		store[c] = a
+	}
+	for c, a := range ds.extra {
+		store[c] = a
+	}
+	return store
+}
+
+func (ds *overlaySpecificationSet) Range(f func(byte, Appender) bool) {
+	store := ds.snapshot()
+	for _, c := range sortedKeys(store) {
+		if !f(c, store[c]) {
+			return
+		}
+	}
+}
+
+// specificationRanger is implemented by sets that can list their
+// specifications. Sets that do not implement it are listed by looking up
+// every character
+type specificationRanger interface {
+	Range(func(byte, Appender) bool)
+}
+
+// collectSpecifications returns a copy of the specifications in ds
+func collectSpecifications(ds SpecificationSet) map[byte]Appender {
+	store := make(map[byte]Appender)
+	if r, ok := ds.(specificationRanger); ok {
+		r.Range(func(c byte, a Appender) bool {
+			store[c] = a
+			return true
+		})
+		return store
+	}
+	for c := 0; c < 256; c++ {
+		if a, err := ds.Lookup(byte(c)); err == nil {
+			store[byte(c)] = a
+		}
+	}
+	return store
+}
+
+// CloneSpecificationSet returns a mutable copy of ds, which can be
+// changed without affecting ds.
+func CloneSpecificationSet(ds SpecificationSet) SpecificationSet {
+	if raw, ok := ds.(*specificationSet); ok {
+		return raw.clone()
+	}
+	return newSpecificationSet(collectSpecifications(ds))
+}
+
+// SpecificationSetKeys returns the characters that are specified in ds,
+// in ascending order.
+func SpecificationSetKeys(ds SpecificationSet) []byte {
+	return sortedKeys(collectSpecifications(ds))
+}
+
+// RangeSpecificationSet calls f for each specification in ds, in
+// ascending order of the characters, until f returns false. It iterates
+// over a snapshot of ds, so f may modify ds.
+//
+// Sets that are not created by this package are listed using their
+// `Range(func(byte, Appender) bool)` method if they have one, or by
+// looking up every character otherwise.
+func RangeSpecificationSet(ds SpecificationSet, f func(byte, Appender) bool) {
+	store := collectSpecifications(ds)
+	for _, c := range sortedKeys(store) {
+		if !f(c, store[c]) {
+			return
+		}
+	}
+}
+
+// MergeSpecificationSet adds the specifications in src to dst. The policy
+// decides what happens to the characters that are in both sets with
+// different Appenders. If the merge fails, dst is left unchanged.
+//
+// For sets created by this package, the merge is applied atomically.
+// Other sets are updated using Set, one specification at a time.
+func MergeSpecificationSet(dst, src SpecificationSet, policy MergePolicy) error {
+	incoming := collectSpecifications(src)
+	if raw, ok := dst.(*specificationSet); ok {
+		return raw.update(`merge`, func(store map[byte]Appender) error {
+			return merge(store, incoming, policy)
+		})
+	}
+
+	current := collectSpecifications(dst)
+	updated := make(map[byte]Appender, len(current))
+	for c, a := range current {
+		updated[c] = a
+	}
+	if err := merge(updated, incoming, policy); err != nil {
+		return err
+	}
+	for _, c := range sortedKeys(updated) {
+		if existing, ok := current[c]; ok && sameAppender(existing, updated[c]) {
+			continue
+		}
+		if err := dst.Set(c, updated[c]); err != nil {
+			return fmt.Errorf(`merge failed: %w`, err)
+		}
+	}
 	return nil
 }
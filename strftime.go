@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"strings"
 	"sync"
 	"time"
 )
@@ -32,45 +31,230 @@ func (ae *appenderExecutor) handle(a Appender) {
 	ae.dst = a.Append(ae.dst, ae.t)
 }
 
-func compile(handler compileHandler, p string, ds SpecificationSet) error {
-	for l := len(p); l > 0; l = len(p) {
-		// This is a really tight loop, so we don't even calls to
-		// Verbatim() to cuase extra stuff
-		var verbatim verbatimw
+// compileState holds the state that directives such as `%{in:UTC}`
+// carry over to the tokens that follow them
+type compileState struct {
+	// if non-nil, the following specifications are rendered in this location
+	loc *time.Location
+	// optional sections that are currently open. Appenders are added to
+	// the innermost section instead of being passed to the handler
+	groups []*combiningAppend
+}
 
-		i := strings.IndexByte(p, '%')
-		if i < 0 {
-			verbatim.s = p
-			handler.handle(&verbatim)
-			// this is silly, but I don't trust break keywords when there's a
-			// possibility of this piece of code being rearranged
-			p = p[l:]
-			continue
+func (st *compileState) emit(handler compileHandler, a Appender) {
+	if n := len(st.groups); n > 0 {
+		st.groups[n-1].Append(a)
+		return
+	}
+	handler.handle(a)
+}
+
+// finish checks that the pattern did not leave anything unfinished
+func (st *compileState) finish() error {
+	if len(st.groups) > 0 {
+		return errors.New(`pattern compilation failed: unterminated optional section`)
+	}
+	return nil
+}
+
+func compile(handler compileHandler, p string, cfg *compileConfig) error {
+	var st compileState
+	for pos := 0; pos < len(p); {
+		tok, err := nextToken(p, pos, cfg.registered)
+		if err != nil {
+			return err
 		}
-		if i == l-1 {
-			return errors.New(`stray % at the end of pattern`)
+		if err := compileToken(handler, tok, cfg, &st); err != nil {
+			return err
 		}
+		pos = tok.End
+	}
+	return st.finish()
+}
 
-		// we found a '%'. we need the next byte to decide what to do next
-		// we already know that i < l - 1
-		// everything up to the i is verbatim
-		if i > 0 {
-			verbatim.s = p[:i]
-			handler.handle(&verbatim)
-			p = p[i:]
+// compileTokens is the same as compile, for tokens that have already been
+// tokenized
+func compileTokens(handler compileHandler, tokens []Token, cfg *compileConfig) error {
+	var st compileState
+	for _, tok := range tokens {
+		if err := compileToken(handler, tok, cfg, &st); err != nil {
+			return err
 		}
+	}
+	return st.finish()
+}
+
+// directiveIn is the name of the `%{in:Location}` directive, which
+// switches the location that the following specifications are rendered in
+const directiveIn = `in`
 
-		specification, err := ds.Lookup(p[1])
+// time.LoadLocation reads the zoneinfo database every time it is called,
+// so locations used in patterns are cached
+var locationCache sync.Map
+
+func loadLocation(name string) (*time.Location, error) {
+	if v, ok := locationCache.Load(name); ok {
+		return v.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locationCache.Store(name, loc)
+	return loc, nil
+}
+
+func compileToken(handler compileHandler, tok Token, cfg *compileConfig, st *compileState) error {
+	switch {
+	case tok.Kind == TokenVerbatim:
+		st.emit(handler, &verbatimw{s: tok.Text})
+		return nil
+	case tok.Kind == TokenOptionalStart:
+		st.groups = append(st.groups, &combiningAppend{})
+		return nil
+	case tok.Kind == TokenOptionalEnd:
+		n := len(st.groups)
+		if n == 0 {
+			return fmt.Errorf(`pattern compilation failed: %s without a matching %%[ at offset %d`, tok.Text, tok.Start)
+		}
+		group := st.groups[n-1]
+		st.groups = st.groups[:n-1]
+		st.emit(handler, &optionalSection{list: group.list})
+		return nil
+	case tok.Kind == TokenNamed && tok.Name == directiveIn:
+		// `%{in}` goes back to the location of the time being formatted
+		if tok.Argument == "" {
+			st.loc = nil
+			return nil
+		}
+		loc, err := loadLocation(tok.Argument)
 		if err != nil {
-			return fmt.Errorf("pattern compilation failed: %w", err)
+			return fmt.Errorf("pattern compilation failed: invalid location in %s: %w", tok.Text, err)
 		}
+		st.loc = loc
+		return nil
+	}
 
-		handler.handle(specification)
-		p = p[2:]
+	specification, err := cfg.appenderFor(tok)
+	if err != nil {
+		return fmt.Errorf("pattern compilation failed: %w", err)
+	}
+	if st.loc != nil {
+		specification = &inLocation{loc: st.loc, appender: specification}
 	}
+	st.emit(handler, specification)
 	return nil
 }
 
+// compileConfig holds everything that was specified through options,
+// and is required to compile a pattern
+type compileConfig struct {
+	ds    SpecificationSet
+	named map[string]Appender
+	// if non-nil, times are converted to this location before formatting
+	loc *time.Location
+	// used by specifications that are relative to the current time
+	clock              Clock
+	relativeThresholds RelativeTimeThresholds
+	// if non-nil, date specifications are rendered in this calendar
+	calendar Calendar
+}
+
+// lookup returns the Appender for the specification character. If a
+// calendar was specified, the default date specifications are replaced
+// with ones that consult the calendar
+func (cfg *compileConfig) lookup(c byte) (Appender, error) {
+	a, err := cfg.ds.Lookup(c)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.calendar != nil && specificationIn(c, calendarSpecifications) {
+		// specifications that the user replaced are left untouched
+		if def, ok := defaultSpecifications[c]; ok && sameAppender(def, a) {
+			return calendarAppender{calendar: cfg.calendar, specification: c}, nil
+		}
+	}
+	return a, nil
+}
+
+// registered reports if c is registered as a specification
+func (cfg *compileConfig) registered(c byte) bool {
+	_, err := cfg.ds.Lookup(c)
+	return err == nil
+}
+
+// tokenize splits the pattern into tokens, keeping the meaning of the
+// specifications that are registered in the specification set
+func (cfg *compileConfig) tokenize(p string) ([]Token, error) {
+	return tokenize(p, cfg.registered)
+}
+
+// appenderFor returns the Appender for a non-verbatim token
+func (cfg *compileConfig) appenderFor(tok Token) (Appender, error) {
+	switch tok.Kind {
+	case TokenSpecification:
+		specification, err := cfg.lookup(tok.Specification)
+		if err != nil {
+			return nil, err
+		}
+		if tok.Flags != "" || tok.Width > 0 {
+			specification = &flaggedAppender{
+				appender: specification,
+				flags:    tok.Flags,
+				width:    tok.Width,
+			}
+		}
+		return specification, nil
+	case TokenNamed:
+		if specification, ok := cfg.named[tok.Name]; ok {
+			return specification, nil
+		}
+		switch tok.Name {
+		case namedAgo:
+			return RelativeTime(cfg.clock, cfg.relativeThresholds), nil
+		case namedEra:
+			if cfg.calendar == nil {
+				return nil, fmt.Errorf(`named specification '%%{%s}' requires a calendar (see WithCalendar)`, tok.Name)
+			}
+			return eraAppender{calendar: cfg.calendar}, nil
+		}
+		return nil, fmt.Errorf(`named specification '%%{%s}' was not found`, tok.Name)
+	default:
+		return nil, fmt.Errorf(`unknown token kind %s`, tok.Kind)
+	}
+}
+
+func newCompileConfig(options ...Option) (compileConfig, error) {
+	cfg := compileConfig{
+		relativeThresholds: DefaultRelativeTimeThresholds(),
+	}
+	ds, err := getSpecificationSetFor(options...)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to get specification set: %w", err)
+	}
+	cfg.ds = ds
+
+	for _, option := range options {
+		switch option.Name() {
+		case optNamedSpecification:
+			pair := option.Value().(*optNamedSpecificationPair)
+			if cfg.named == nil {
+				cfg.named = make(map[string]Appender)
+			}
+			cfg.named[pair.name] = pair.appender
+		case optLocation:
+			cfg.loc = option.Value().(*time.Location)
+		case optClock:
+			cfg.clock = option.Value().(Clock)
+		case optRelativeTimeThresholds:
+			cfg.relativeThresholds = option.Value().(RelativeTimeThresholds)
+		case optCalendar:
+			cfg.calendar = option.Value().(Calendar)
+		}
+	}
+	return cfg, nil
+}
+
 func getSpecificationSetFor(options ...Option) (SpecificationSet, error) {
 	var ds SpecificationSet = defaultSpecificationSet
 	var extraSpecifications []*optSpecificationPair
@@ -84,10 +268,14 @@ func getSpecificationSetFor(options ...Option) (SpecificationSet, error) {
 	}
 
 	if len(extraSpecifications) > 0 {
-		// If ds is immutable, we're going to need to create a new
-		// one. oh what a waste!
-		if raw, ok := ds.(*specificationSet); ok && !raw.mutable {
-			ds = NewSpecificationSet()
+		// If ds is immutable, layer the extra specifications over it
+		// instead of copying it
+		if isFrozen(ds) {
+			extra := make(map[byte]Appender, len(extraSpecifications))
+			for _, v := range extraSpecifications {
+				extra[v.name] = v.appender
+			}
+			return newOverlaySpecificationSet(ds, extra), nil
 		}
 		for _, v := range extraSpecifications {
 			if err := ds.Set(v.name, v.appender); err != nil {
@@ -125,15 +313,18 @@ func releasdeFmtAppendExecutor(v *appenderExecutor) {
 // and reusing it.
 func Format(p string, t time.Time, options ...Option) (string, error) {
 	// TODO: this may be premature optimization
-	ds, err := getSpecificationSetFor(options...)
+	cfg, err := newCompileConfig(options...)
 	if err != nil {
-		return "", fmt.Errorf("failed to get specification set: %w", err)
+		return "", err
 	}
 	h := getFmtAppendExecutor()
 	defer releasdeFmtAppendExecutor(h)
 
+	if cfg.loc != nil {
+		t = t.In(cfg.loc)
+	}
 	h.t = t
-	if err := compile(h, p, ds); err != nil {
+	if err := compile(h, p, &cfg); err != nil {
 		return "", fmt.Errorf("failed to compile format: %w", err)
 	}
 
@@ -144,27 +335,49 @@ func Format(p string, t time.Time, options ...Option) (string, error) {
 type Strftime struct {
 	pattern  string
 	compiled appenderList
+	cfg      compileConfig
+	// the tokens that were compiled. Explain and Parse use them instead
+	// of tokenizing the pattern again
+	tokens []Token
+
+	// the parser is compiled on the first call to Parse
+	parserOnce  sync.Once
+	parsers     []fieldParser
+	parserErr   error
+	parserStart *[256]bool
 }
 
 // New creates a new Strftime object. If the compilation fails, then
 // an error is returned in the second argument.
 func New(p string, options ...Option) (*Strftime, error) {
 	// TODO: this may be premature optimization
-	ds, err := getSpecificationSetFor(options...)
+	cfg, err := newCompileConfig(options...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get specification set: %w", err)
+		return nil, err
+	}
+
+	var tokens []Token
+	for pos := 0; pos < len(p); {
+		tok, err := nextToken(p, pos, cfg.registered)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile format: %w", err)
+		}
+		tokens = append(tokens, tok)
+		pos = tok.End
 	}
 
 	var h appenderListBuilder
 	h.list = &combiningAppend{}
 
-	if err := compile(&h, p, ds); err != nil {
+	if err := compileTokens(&h, tokens, &cfg); err != nil {
 		return nil, fmt.Errorf("failed to compile format: %w", err)
 	}
 
 	return &Strftime{
 		pattern:  p,
 		compiled: h.list.list,
+		cfg:      cfg,
+		tokens:   tokens,
 	}, nil
 }
 
@@ -201,11 +414,17 @@ func (f *Strftime) FormatBuffer(dst []byte, t time.Time) []byte {
 // Dump outputs the internal structure of the formatter, for debugging purposes.
 // Please do NOT assume the output format to be fixed: it is expected to change
 // in the future.
+//
+// Deprecated: use Explain, which produces output that is meant to be
+// read by humans.
 func (f *Strftime) Dump(out io.Writer) {
 	f.compiled.dump(out)
 }
 
 func (f *Strftime) format(b []byte, t time.Time) []byte {
+	if f.cfg.loc != nil {
+		t = t.In(f.cfg.loc)
+	}
 	for _, w := range f.compiled {
 		b = w.Append(b, t)
 	}
@@ -3,7 +3,9 @@ package strftime_test
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -596,3 +598,1723 @@ func TestFormat_WeekYearBoundaries(t *testing.T) {
         assert.Equal(t, expectedg, gotg, "Week year without century should match for %v", testDate)
     }
 }
+
+func TestExplain(t *testing.T) {
+	p, err := strftime.New(`%G-W%V-%u`)
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+
+	var buf bytes.Buffer
+	if !assert.NoError(t, p.Explain(&buf), `Explain should succeed`) {
+		return
+	}
+
+	out := buf.String()
+	for _, expected := range []string{
+		"example:        2006-W01-1\n",
+		`the ISO week year with century as a decimal number with 4 digits`,
+		`verbatim text`,
+		`"-W"`,
+		`%G: ISO week year differs from %Y around new year`,
+	} {
+		if !assert.Contains(t, out, expected, `output should contain %q`, expected) {
+			return
+		}
+	}
+
+	p, err = strftime.New(`%L`, strftime.WithMilliseconds('L'))
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+	buf.Reset()
+	if !assert.NoError(t, p.Explain(&buf), `Explain should succeed`) {
+		return
+	}
+	if !assert.Contains(t, buf.String(), `the milliseconds as a zero-padded`, `extensions should be described`) {
+		return
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	const pattern = `at %-d %_5H%Ey %{foo:bar} 100%%`
+	tokens, err := strftime.Tokenize(pattern)
+	if !assert.NoError(t, err, `strftime.Tokenize should succeed`) {
+		return
+	}
+
+	expected := []strftime.Token{
+		{Kind: strftime.TokenVerbatim, Start: 0, End: 3, Text: `at `},
+		{Kind: strftime.TokenSpecification, Start: 3, End: 6, Text: `%-d`, Specification: 'd', Flags: `-`},
+		{Kind: strftime.TokenVerbatim, Start: 6, End: 7, Text: ` `},
+		{Kind: strftime.TokenSpecification, Start: 7, End: 11, Text: `%_5H`, Specification: 'H', Flags: `_`, Width: 5},
+		{Kind: strftime.TokenSpecification, Start: 11, End: 14, Text: `%Ey`, Specification: 'y', Modifier: 'E'},
+		{Kind: strftime.TokenVerbatim, Start: 14, End: 15, Text: ` `},
+		{Kind: strftime.TokenNamed, Start: 15, End: 25, Text: `%{foo:bar}`, Name: `foo`, Argument: `bar`},
+		{Kind: strftime.TokenVerbatim, Start: 25, End: 29, Text: ` 100`},
+		{Kind: strftime.TokenSpecification, Start: 29, End: 31, Text: `%%`, Specification: '%'},
+	}
+	if !assert.Equal(t, expected, tokens, `tokens should match`) {
+		return
+	}
+
+	for _, tok := range tokens {
+		if !assert.Equal(t, tok.Text, pattern[tok.Start:tok.End], `spans should match text`) {
+			return
+		}
+	}
+
+	for _, invalid := range []string{`%`, `foo %`, `%{foo`, `%{}`} {
+		_, err := strftime.Tokenize(invalid)
+		if !assert.Error(t, err, `strftime.Tokenize(%q) should fail`, invalid) {
+			return
+		}
+	}
+}
+
+func TestNewFromTokens(t *testing.T) {
+	tokens, err := strftime.Tokenize(`%Y-%m-%d`)
+	if !assert.NoError(t, err, `strftime.Tokenize should succeed`) {
+		return
+	}
+
+	// swap the date for the ISO week date
+	tokens[0].Specification = 'G'
+	tokens[2].Specification = 'V'
+	tokens[4] = strftime.Token{Kind: strftime.TokenSpecification, Specification: 'u'}
+
+	p, err := strftime.NewFromTokens(tokens)
+	if !assert.NoError(t, err, `strftime.NewFromTokens should succeed`) {
+		return
+	}
+	if !assert.Equal(t, `%G-%V-%u`, p.Pattern(), `pattern should match`) {
+		return
+	}
+	if !assert.Equal(t, `2006-01-1`, p.FormatString(ref), `formatted result matches`) {
+		return
+	}
+
+	// the pattern "%-5d" reads back as the day of the month with a width,
+	// but Explain and Parse use the tokens that were compiled
+	p, err = strftime.NewFromTokens([]strftime.Token{
+		{Kind: strftime.TokenSpecification, Flags: `-`, Specification: '5'},
+		{Kind: strftime.TokenVerbatim, Text: `d`},
+	}, strftime.WithSpecification('5', strftime.Verbatim(`U`)))
+	if !assert.NoError(t, err, `strftime.NewFromTokens should succeed`) {
+		return
+	}
+	if !assert.Equal(t, `%-5d`, p.Pattern(), `pattern should match`) {
+		return
+	}
+	if !assert.Equal(t, `Ud`, p.FormatString(ref), `formatted result matches`) {
+		return
+	}
+	var buf bytes.Buffer
+	if !assert.NoError(t, p.Explain(&buf), `Explain should succeed`) {
+		return
+	}
+	if !assert.Contains(t, buf.String(), `"d"  verbatim text`, `Explain uses the compiled tokens`) {
+		return
+	}
+	if !assert.NotContains(t, buf.String(), `day of the month`, `Explain uses the compiled tokens`) {
+		return
+	}
+	_, err = p.Parse(`Ud`)
+	if !assert.ErrorContains(t, err, `parsing %-5 is not supported`, `Parse uses the compiled tokens`) {
+		return
+	}
+}
+
+func TestFlags(t *testing.T) {
+	dt := time.Date(2006, time.January, 2, 3, 4, 5, 0, time.UTC)
+	testcases := []struct {
+		pattern  string
+		expected string
+	}{
+		{pattern: `%-d`, expected: `2`},
+		{pattern: `%-e`, expected: `2`},
+		{pattern: `%_d`, expected: ` 2`},
+		{pattern: `%0e`, expected: `02`},
+		{pattern: `%-H:%M`, expected: `3:04`},
+		{pattern: `%5d`, expected: `00002`},
+		{pattern: `%5e`, expected: `    2`},
+		{pattern: `%_5d`, expected: `    2`},
+		{pattern: `%10A`, expected: `    Monday`},
+		{pattern: `%^a`, expected: `MON`},
+		{pattern: `%^B`, expected: `JANUARY`},
+		{pattern: `%#a`, expected: `MON`},
+		{pattern: `%#Z`, expected: `utc`},
+		{pattern: `%Ey %Od`, expected: `06 02`},
+		{pattern: `%-Y`, expected: `2006`},
+	}
+
+	for _, tc := range testcases {
+		s, err := strftime.Format(tc.pattern, dt)
+		if !assert.NoError(t, err, `strftime.Format(%q) should succeed`, tc.pattern) {
+			return
+		}
+		if !assert.Equal(t, tc.expected, s, `strftime.Format(%q) should match`, tc.pattern) {
+			return
+		}
+	}
+}
+
+func TestFlagCharacterSpecifications(t *testing.T) {
+	// custom specifications on characters that are also part of the
+	// flag/width grammar keep working as they did before flags existed
+	dt := time.Date(2023, time.January, 2, 3, 4, 5, 0, time.UTC)
+	testcases := []struct {
+		pattern  string
+		c        byte
+		expected string
+	}{
+		{pattern: `%_%Y`, c: '_', expected: `U2023`},
+		{pattern: `%0d`, c: '0', expected: `Ud`},
+		{pattern: `%-d`, c: '-', expected: `Ud`},
+		{pattern: `%^a`, c: '^', expected: `Ua`},
+		{pattern: `%#Z`, c: '#', expected: `UZ`},
+		{pattern: `%5d`, c: '5', expected: `Ud`},
+		{pattern: `%{Y`, c: '{', expected: `UY`},
+		{pattern: `%[%Y`, c: '[', expected: `U2023`},
+		{pattern: `%EY`, c: 'E', expected: `UY`},
+		{pattern: `%Od`, c: 'O', expected: `Ud`},
+	}
+
+	for _, tc := range testcases {
+		s, err := strftime.Format(tc.pattern, dt, strftime.WithSpecification(tc.c, strftime.Verbatim(`U`)))
+		if !assert.NoError(t, err, `strftime.Format(%q) should succeed`, tc.pattern) {
+			return
+		}
+		if !assert.Equal(t, tc.expected, s, `strftime.Format(%q) should match`, tc.pattern) {
+			return
+		}
+	}
+
+	// flags still work for characters that are not registered
+	s, err := strftime.Format(`%-d %_%`, dt, strftime.WithSpecification('0', strftime.Verbatim(`U`)))
+	if !assert.NoError(t, err, `strftime.Format should succeed`) {
+		return
+	}
+	if !assert.Equal(t, `2 %`, s, `flags are applied`) {
+		return
+	}
+}
+
+func TestWithNamedSpecification(t *testing.T) {
+	_, err := strftime.New(`%{name}`)
+	if !assert.Error(t, err, `unknown named specifications should fail`) {
+		return
+	}
+
+	p, err := strftime.New(`%Y %{name}`, strftime.WithNamedSpecification(`name`, strftime.Verbatim(`Daisuke Maki`)))
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+	if !assert.Equal(t, `2006 Daisuke Maki`, p.FormatString(ref), `formatted result matches`) {
+		return
+	}
+}
+
+func TestLint(t *testing.T) {
+	codesOf := func(diagnostics []strftime.Diagnostic) []strftime.DiagnosticCode {
+		var codes []strftime.DiagnosticCode
+		for _, d := range diagnostics {
+			codes = append(codes, d.Code)
+		}
+		return codes
+	}
+
+	testcases := []struct {
+		pattern  string
+		options  []strftime.Option
+		expected []strftime.DiagnosticCode
+	}{
+		{pattern: `%Y%m%d-%H%M%S%z`},
+		{pattern: `%G-W%V-%u`},
+		{pattern: `%Y-%m-%d %`, expected: []strftime.DiagnosticCode{strftime.LintInvalidPattern}},
+		{pattern: `%Y-%m-%d %Q`, expected: []strftime.DiagnosticCode{strftime.LintUnknownSpecification}},
+		{pattern: `%Y-%m-%d %L`, options: []strftime.Option{strftime.WithMilliseconds('L')}},
+		{pattern: `%Y-W%V`, expected: []strftime.DiagnosticCode{strftime.LintMixedWeekYear}},
+		{pattern: `%G-%m-%d`, expected: []strftime.DiagnosticCode{strftime.LintMixedWeekYear}},
+		{pattern: `%I:%M`, expected: []strftime.DiagnosticCode{strftime.LintTwelveHourWithoutAMPM, strftime.LintNonSortable}},
+		{pattern: `%I:%M %p`, expected: []strftime.DiagnosticCode{strftime.LintNonSortable}},
+		{pattern: `%y%m%d`, expected: []strftime.DiagnosticCode{strftime.LintTwoDigitYear}},
+		{pattern: `%F %T`, expected: []strftime.DiagnosticCode{strftime.LintMissingZone}},
+		{pattern: `%d-%m-%Y`, expected: []strftime.DiagnosticCode{strftime.LintNonSortable}},
+		{pattern: `%Y-%b-%d`, expected: []strftime.DiagnosticCode{strftime.LintNonSortable}},
+		{pattern: `%Y%-m%d`, expected: []strftime.DiagnosticCode{strftime.LintNonSortable}},
+		{
+			pattern:  `%d-%m-%y`,
+			options:  []strftime.Option{strftime.WithSuppressedDiagnostics(strftime.LintTwoDigitYear)},
+			expected: []strftime.DiagnosticCode{strftime.LintNonSortable},
+		},
+	}
+
+	for _, tc := range testcases {
+		diagnostics := strftime.Lint(tc.pattern, tc.options...)
+		if !assert.Equal(t, tc.expected, codesOf(diagnostics), `strftime.Lint(%q) should report %v, got %v`, tc.pattern, tc.expected, diagnostics) {
+			return
+		}
+	}
+
+	diagnostics := strftime.Lint(`%Y-W%V`)
+	if !assert.Len(t, diagnostics, 1, `there should be one diagnostic`) {
+		return
+	}
+	if !assert.Equal(t, 0, diagnostics[0].Start, `span should start at %%Y`) {
+		return
+	}
+	if !assert.Equal(t, 2, diagnostics[0].End, `span should end after %%Y`) {
+		return
+	}
+}
+
+func TestWithLocation(t *testing.T) {
+	jst := time.FixedZone("JST", 9*3600)
+
+	p, err := strftime.New(`%Y-%m-%d %H:%M:%S %Z %z`, strftime.WithLocation(jst))
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+	const expected = `2006-01-03 07:04:05 JST +0900`
+	if !assert.Equal(t, expected, p.FormatString(ref), `time should be converted to JST`) {
+		return
+	}
+	if !assert.Equal(t, expected, string(p.FormatBuffer(nil, ref.In(time.FixedZone("EST", -5*3600)))), `any location should be converted to JST`) {
+		return
+	}
+
+	s, err := strftime.Format(`%H %Z`, ref.In(jst), strftime.WithUTC())
+	if !assert.NoError(t, err, `strftime.Format should succeed`) {
+		return
+	}
+	if !assert.Equal(t, `22 UTC`, s, `time should be converted to UTC`) {
+		return
+	}
+}
+
+func TestInLocation(t *testing.T) {
+	p, err := strftime.New(`%H:%M %Z / %{in:Asia/Tokyo}%H:%M %Z / %{in:UTC}%H:%M %Z / %{in}%H:%M %Z`)
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+
+	est := time.FixedZone("EST", -5*3600)
+	const expected = `17:04 EST / 07:04 JST / 22:04 UTC / 17:04 EST`
+	if !assert.Equal(t, expected, p.FormatString(ref.In(est)), `each section should be rendered in its location`) {
+		return
+	}
+
+	s, err := strftime.Format(`%{in:Asia/Tokyo}%F`, ref)
+	if !assert.NoError(t, err, `strftime.Format should succeed`) {
+		return
+	}
+	if !assert.Equal(t, `2006-01-03`, s, `date should be in Asia/Tokyo`) {
+		return
+	}
+
+	_, err = strftime.New(`%{in:Nowhere/Nothing}%H`)
+	if !assert.Error(t, err, `unknown locations should fail`) {
+		return
+	}
+}
+
+func TestWithZoneName(t *testing.T) {
+	tokyo, err := time.LoadLocation(`Asia/Tokyo`)
+	if !assert.NoError(t, err, `time.LoadLocation should succeed`) {
+		return
+	}
+
+	p, err := strftime.New(`%Y-%m-%d %H:%M %Q`, strftime.WithZoneName('Q'))
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+
+	testcases := []struct {
+		t        time.Time
+		expected string
+	}{
+		{t: ref.In(tokyo), expected: `2006-01-03 07:04 Asia/Tokyo`},
+		{t: ref, expected: `2006-01-02 22:04 UTC`},
+		{t: ref.In(time.FixedZone(`JST`, 9*3600)), expected: `2006-01-03 07:04 +09:00`},
+		{t: ref.In(time.FixedZone(`Asia/Tokyo`, 3600)), expected: `2006-01-02 23:04 +01:00`},
+	}
+	for _, tc := range testcases {
+		if !assert.Equal(t, tc.expected, p.FormatString(tc.t), `formatted result matches`) {
+			return
+		}
+	}
+}
+
+func TestOptionalSection(t *testing.T) {
+	p, err := strftime.New(`%H:%M:%S%[.%3N%]`, strftime.WithFractionalSeconds('N'))
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+
+	testcases := []struct {
+		t        time.Time
+		expected string
+	}{
+		{t: ref, expected: `22:04:05.123`},
+		{t: ref.Truncate(time.Second), expected: `22:04:05`},
+		{t: ref.Truncate(time.Second).Add(time.Microsecond), expected: `22:04:05.000`},
+	}
+	for _, tc := range testcases {
+		if !assert.Equal(t, tc.expected, p.FormatString(tc.t), `formatted result matches`) {
+			return
+		}
+		s, err := strftime.Format(p.Pattern(), tc.t, strftime.WithFractionalSeconds('N'))
+		if !assert.NoError(t, err, `strftime.Format should succeed`) {
+			return
+		}
+		if !assert.Equal(t, tc.expected, s, `formatted result matches`) {
+			return
+		}
+	}
+
+	// sections without anything that reports zero values are always rendered
+	p, err = strftime.New(`%Y%[-%m%]`)
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+	if !assert.Equal(t, `2006-01`, p.FormatString(ref), `formatted result matches`) {
+		return
+	}
+
+	// nested sections
+	p, err = strftime.New(`%S%[.%L%[%f%]%]`, strftime.WithMilliseconds('L'), strftime.WithMicroseconds('f'))
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+	if !assert.Equal(t, `05.123123456`, p.FormatString(ref), `formatted result matches`) {
+		return
+	}
+	if !assert.Equal(t, `05`, p.FormatString(ref.Truncate(time.Second)), `formatted result matches`) {
+		return
+	}
+
+	for _, invalid := range []string{`%[%S`, `%S%]`, `%[%[%S%]`} {
+		_, err := strftime.New(invalid)
+		if !assert.Error(t, err, `strftime.New(%q) should fail`, invalid) {
+			return
+		}
+	}
+}
+
+func TestFractionalSeconds(t *testing.T) {
+	for pattern, expected := range map[string]string{
+		`%N`:   `123456789`,
+		`%3N`:  `123`,
+		`%6N`:  `123456`,
+		`%1N`:  `1`,
+		`%12N`: `123456789`,
+	} {
+		s, err := strftime.Format(pattern, ref, strftime.WithFractionalSeconds('N'))
+		if !assert.NoError(t, err, `strftime.Format should succeed`) {
+			return
+		}
+		if !assert.Equal(t, expected, s, `strftime.Format(%q) should match`, pattern) {
+			return
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	now := time.Date(2023, time.March, 3, 14, 3, 0, 0, time.UTC)
+	clock := strftime.ClockFunc(func() time.Time { return now })
+
+	mustNew := func(p string) *strftime.Strftime {
+		f, err := strftime.New(p)
+		if err != nil {
+			t.Fatalf("strftime.New(%q) failed: %s", p, err)
+		}
+		return f
+	}
+
+	s, err := strftime.NewSelect(
+		mustNew(`%Y-%m-%d`),
+		strftime.WithCase(strftime.Within(time.Hour), mustNew(`%H:%M`)),
+		strftime.WithCase(strftime.SameDay(), mustNew(`today %H:%M`)),
+		strftime.WithCase(strftime.SameYear(), mustNew(`%b %-d`)),
+		strftime.WithClock(clock),
+	)
+	if !assert.NoError(t, err, `strftime.NewSelect should succeed`) {
+		return
+	}
+
+	var _ strftime.Formatter = s
+
+	testcases := []struct {
+		t        time.Time
+		expected string
+	}{
+		{t: now.Add(-30 * time.Minute), expected: `13:33`},
+		{t: now.Add(-3 * time.Hour), expected: `today 11:03`},
+		{t: time.Date(2023, time.January, 2, 0, 0, 0, 0, time.UTC), expected: `Jan 2`},
+		{t: time.Date(2022, time.March, 3, 0, 0, 0, 0, time.UTC), expected: `2022-03-03`},
+	}
+	for _, tc := range testcases {
+		if !assert.Equal(t, tc.expected, s.FormatString(tc.t), `formatted result matches`) {
+			return
+		}
+		if !assert.Equal(t, tc.expected, string(s.FormatBuffer(nil, tc.t)), `formatted result matches`) {
+			return
+		}
+		var buf bytes.Buffer
+		if !assert.NoError(t, s.Format(&buf, tc.t), `Format should succeed`) {
+			return
+		}
+		if !assert.Equal(t, tc.expected, buf.String(), `formatted result matches`) {
+			return
+		}
+	}
+
+	_, err = strftime.NewSelect(nil)
+	if !assert.Error(t, err, `strftime.NewSelect without fallback should fail`) {
+		return
+	}
+}
+
+func TestDurationFormatter(t *testing.T) {
+	d := 50*time.Hour + 4*time.Minute + 5*time.Second + 123456789*time.Nanosecond
+	testcases := []struct {
+		pattern  string
+		d        time.Duration
+		expected string
+	}{
+		{pattern: `%H:%M:%S`, d: d, expected: `02:04:05`},
+		{pattern: `%h:%M:%S`, d: d, expected: `50:04:05`},
+		{pattern: `%dd %Hh`, d: d, expected: `2d 02h`},
+		{pattern: `%m minutes, %s seconds`, d: d, expected: `3004 minutes, 180245 seconds`},
+		{pattern: `%S.%L`, d: d, expected: `05.123`},
+		{pattern: `%s.%f`, d: d, expected: `180245.123456789`},
+		{pattern: `%s.%3f`, d: d, expected: `180245.123`},
+		{pattern: `%-H:%M`, d: d, expected: `2:04`},
+		{pattern: `%5h%%`, d: d, expected: `00050%`},
+		{pattern: `uptime: %h:%M:%S`, d: -d, expected: `uptime: -50:04:05`},
+		{pattern: `%H:%M:%S`, d: 0, expected: `00:00:00`},
+	}
+	for _, tc := range testcases {
+		f, err := strftime.NewDurationFormatter(tc.pattern)
+		if !assert.NoError(t, err, `strftime.NewDurationFormatter(%q) should succeed`, tc.pattern) {
+			return
+		}
+		if !assert.Equal(t, tc.expected, f.FormatString(tc.d), `formatted result matches for %q`, tc.pattern) {
+			return
+		}
+		if !assert.Equal(t, tc.expected, string(f.FormatBuffer(nil, tc.d)), `formatted result matches for %q`, tc.pattern) {
+			return
+		}
+	}
+
+	s, err := strftime.FormatDuration(`%h:%M:%S`, math.MinInt64)
+	if !assert.NoError(t, err, `strftime.FormatDuration should succeed`) {
+		return
+	}
+	if !assert.Equal(t, `-2562047:47:16`, s, `formatted result matches`) {
+		return
+	}
+
+	for _, p := range []string{`%Y`, `%{name}`, `%[%S%]`, `%`} {
+		_, err := strftime.NewDurationFormatter(p)
+		if !assert.Error(t, err, `strftime.NewDurationFormatter(%q) should fail`, p) {
+			return
+		}
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2023, time.March, 3, 14, 3, 0, 0, time.UTC)
+	clock := strftime.ClockFunc(func() time.Time { return now })
+
+	f, err := strftime.New(`%{ago}`, strftime.WithClock(clock))
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+
+	testcases := []struct {
+		t        time.Time
+		expected string
+	}{
+		{t: now, expected: `just now`},
+		{t: now.Add(-3 * time.Second), expected: `just now`},
+		{t: now.Add(-30 * time.Second), expected: `30 seconds ago`},
+		{t: now.Add(-90 * time.Second), expected: `1 minute ago`},
+		{t: now.Add(-3 * time.Minute), expected: `3 minutes ago`},
+		{t: now.Add(5 * time.Hour), expected: `in 5 hours`},
+		{t: now.Add(-49 * time.Hour), expected: `2 days ago`},
+		{t: now.Add(24 * time.Hour), expected: `in 1 day`},
+		{t: now.AddDate(0, -3, 0), expected: `3 months ago`},
+		{t: now.AddDate(2, 0, 1), expected: `in 2 years`},
+	}
+	for _, tc := range testcases {
+		if !assert.Equal(t, tc.expected, f.FormatString(tc.t), `formatted result matches`) {
+			return
+		}
+	}
+
+	// never say "just now", and use minutes from the start
+	f, err = strftime.New(`%{ago}`, strftime.WithClock(clock), strftime.WithRelativeTimeThresholds(strftime.RelativeTimeThresholds{
+		Minutes: time.Hour,
+		Hours:   24 * time.Hour,
+	}))
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+	if !assert.Equal(t, `1 minute ago`, f.FormatString(now.Add(-time.Second)), `formatted result matches`) {
+		return
+	}
+	if !assert.Equal(t, `2 years ago`, f.FormatString(now.AddDate(0, 0, -800)), `formatted result matches`) {
+		return
+	}
+
+	// user defined named specifications take precedence
+	f, err = strftime.New(`%{ago}`, strftime.WithNamedSpecification(`ago`, strftime.Verbatim(`custom`)))
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+	if !assert.Equal(t, `custom`, f.FormatString(now), `formatted result matches`) {
+		return
+	}
+}
+
+func TestFiscal(t *testing.T) {
+	t.Run("Quarter and HalfYear", func(t *testing.T) {
+		f, err := strftime.New(`%Y-Q%q-H%h`, strftime.WithQuarter('q'), strftime.WithHalfYear('h'))
+		if !assert.NoError(t, err, `strftime.New should succeed`) {
+			return
+		}
+		if !assert.Equal(t, `2025-Q3-H2`, f.FormatString(time.Date(2025, time.August, 1, 0, 0, 0, 0, time.UTC)), `formatted result matches`) {
+			return
+		}
+		if !assert.Equal(t, `2025-Q1-H1`, f.FormatString(time.Date(2025, time.March, 31, 0, 0, 0, 0, time.UTC)), `formatted result matches`) {
+			return
+		}
+	})
+	t.Run("Fiscal year starting in April", func(t *testing.T) {
+		fc := strftime.FiscalCalendar{StartMonth: time.April}
+		f, err := strftime.New(`FY%f-Q%q-P%P`, strftime.WithFiscalYear('f', fc), strftime.WithFiscalQuarter('q', fc), strftime.WithFiscalPeriod('P', fc))
+		if !assert.NoError(t, err, `strftime.New should succeed`) {
+			return
+		}
+		testcases := []struct {
+			t        time.Time
+			expected string
+		}{
+			{t: time.Date(2025, time.October, 15, 0, 0, 0, 0, time.UTC), expected: `FY2025-Q3-P07`},
+			{t: time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC), expected: `FY2025-Q1-P01`},
+			{t: time.Date(2026, time.March, 31, 0, 0, 0, 0, time.UTC), expected: `FY2025-Q4-P12`},
+		}
+		for _, tc := range testcases {
+			if !assert.Equal(t, tc.expected, f.FormatString(tc.t), `formatted result matches`) {
+				return
+			}
+		}
+	})
+	t.Run("Fiscal year named by end year", func(t *testing.T) {
+		fc := strftime.FiscalCalendar{StartMonth: time.October, NameByEndYear: true}
+		f, err := strftime.New(`FY%f-Q%q`, strftime.WithFiscalYear('f', fc), strftime.WithFiscalQuarter('q', fc))
+		if !assert.NoError(t, err, `strftime.New should succeed`) {
+			return
+		}
+		if !assert.Equal(t, `FY2025-Q1`, f.FormatString(time.Date(2024, time.October, 1, 0, 0, 0, 0, time.UTC)), `formatted result matches`) {
+			return
+		}
+		if !assert.Equal(t, `FY2025-Q4`, f.FormatString(time.Date(2025, time.September, 30, 0, 0, 0, 0, time.UTC)), `formatted result matches`) {
+			return
+		}
+	})
+	t.Run("4-4-5 calendar", func(t *testing.T) {
+		fc := strftime.FiscalCalendar{StartMonth: time.February, Weeks445: true, StartWeekday: time.Sunday}
+		f, err := strftime.New(`FY%f-Q%q-P%P`, strftime.WithFiscalYear('f', fc), strftime.WithFiscalQuarter('q', fc), strftime.WithFiscalPeriod('P', fc))
+		if !assert.NoError(t, err, `strftime.New should succeed`) {
+			return
+		}
+		testcases := []struct {
+			t        time.Time
+			expected string
+		}{
+			// FY2023 starts on 2023-01-29, and has 53 weeks
+			{t: time.Date(2023, time.January, 29, 0, 0, 0, 0, time.UTC), expected: `FY2023-Q1-P01`},
+			{t: time.Date(2024, time.February, 3, 0, 0, 0, 0, time.UTC), expected: `FY2023-Q4-P12`},
+			// FY2024 starts on 2024-02-04
+			{t: time.Date(2024, time.February, 4, 0, 0, 0, 0, time.UTC), expected: `FY2024-Q1-P01`},
+			{t: time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC), expected: `FY2024-Q4-P12`},
+			// FY2025 starts on 2025-02-02
+			{t: time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC), expected: `FY2025-Q1-P01`},
+			{t: time.Date(2025, time.March, 2, 0, 0, 0, 0, time.UTC), expected: `FY2025-Q1-P02`},
+			{t: time.Date(2025, time.March, 30, 0, 0, 0, 0, time.UTC), expected: `FY2025-Q1-P03`},
+			{t: time.Date(2025, time.May, 4, 0, 0, 0, 0, time.UTC), expected: `FY2025-Q2-P04`},
+		}
+		for _, tc := range testcases {
+			if !assert.Equal(t, tc.expected, f.FormatString(tc.t), `formatted result matches for %s`, tc.t) {
+				return
+			}
+		}
+	})
+}
+
+func TestOrdinals(t *testing.T) {
+	f, err := strftime.New(`%B %o|the %i|%N`, strftime.WithOrdinalDay('o'), strftime.WithSpelledDay('i'), strftime.WithSpelledMonth('N'))
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+
+	testcases := []struct {
+		t        time.Time
+		expected string
+	}{
+		{t: time.Date(2023, time.March, 3, 0, 0, 0, 0, time.UTC), expected: `March 3rd|the third|third`},
+		{t: time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC), expected: `January 1st|the first|first`},
+		{t: time.Date(2023, time.February, 12, 0, 0, 0, 0, time.UTC), expected: `February 12th|the twelfth|second`},
+		{t: time.Date(2023, time.November, 13, 0, 0, 0, 0, time.UTC), expected: `November 13th|the thirteenth|eleventh`},
+		{t: time.Date(2023, time.December, 21, 0, 0, 0, 0, time.UTC), expected: `December 21st|the twenty-first|twelfth`},
+		{t: time.Date(2023, time.May, 22, 0, 0, 0, 0, time.UTC), expected: `May 22nd|the twenty-second|fifth`},
+		{t: time.Date(2023, time.June, 30, 0, 0, 0, 0, time.UTC), expected: `June 30th|the thirtieth|sixth`},
+		{t: time.Date(2023, time.July, 31, 0, 0, 0, 0, time.UTC), expected: `July 31st|the thirty-first|seventh`},
+	}
+	for _, tc := range testcases {
+		if !assert.Equal(t, tc.expected, f.FormatString(tc.t), `formatted result matches`) {
+			return
+		}
+	}
+}
+
+func TestDayCounts(t *testing.T) {
+	f, err := strftime.New(`%J|%i|%Q|%K`,
+		strftime.WithJulianDayNumber('J'),
+		strftime.WithJulianDate('i', 2),
+		strftime.WithModifiedJulianDate('Q', 3),
+		strftime.WithDaysSinceEpoch('K', 5),
+	)
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+
+	testcases := []struct {
+		t        time.Time
+		expected string
+	}{
+		{t: time.Date(2000, time.January, 1, 12, 0, 0, 0, time.UTC), expected: `2451545|2451545.00|51544.500|10957.50000`},
+		{t: time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC), expected: `2451545|2451544.50|51544.000|10957.00000`},
+		{t: time.Date(1858, time.November, 17, 6, 0, 0, 0, time.UTC), expected: `2400001|2400000.75|0.250|-40586.75000`},
+		{t: time.Date(1969, time.December, 31, 18, 0, 0, 500000000, time.UTC), expected: `2440587|2440587.25|40586.750|-0.24999`},
+		// day counts are always in UTC
+		{t: time.Date(2000, time.January, 1, 21, 0, 0, 0, time.FixedZone(`JST`, 9*60*60)), expected: `2451545|2451545.00|51544.500|10957.50000`},
+	}
+	for _, tc := range testcases {
+		if !assert.Equal(t, tc.expected, f.FormatString(tc.t), `formatted result matches for %s`, tc.t) {
+			return
+		}
+	}
+
+	// without fractional digits, days before the epoch are rounded down
+	f, err = strftime.New(`%Q|%K`,
+		strftime.WithModifiedJulianDate('Q', 0),
+		strftime.WithDaysSinceEpoch('K', 0),
+	)
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+	testcases = []struct {
+		t        time.Time
+		expected string
+	}{
+		{t: time.Date(1969, time.December, 31, 12, 0, 0, 0, time.UTC), expected: `40586|-1`},
+		{t: time.Date(1969, time.December, 31, 0, 0, 0, 0, time.UTC), expected: `40586|-1`},
+		{t: time.Date(1969, time.December, 30, 23, 59, 59, 999999999, time.UTC), expected: `40585|-2`},
+		{t: time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC), expected: `40587|0`},
+		{t: time.Date(1858, time.November, 16, 18, 0, 0, 0, time.UTC), expected: `-1|-40588`},
+		{t: time.Date(1858, time.November, 17, 0, 0, 0, 0, time.UTC), expected: `0|-40587`},
+	}
+	for _, tc := range testcases {
+		if !assert.Equal(t, tc.expected, f.FormatString(tc.t), `formatted result matches for %s`, tc.t) {
+			return
+		}
+	}
+}
+
+func TestUnixEpochExtensions(t *testing.T) {
+	f, err := strftime.New(`%s|%L|%U|%N|%F`,
+		strftime.WithUnixSeconds('s'),
+		strftime.WithUnixMilliseconds('L'),
+		strftime.WithUnixMicroseconds('U'),
+		strftime.WithUnixNanoseconds('N'),
+		strftime.WithUnixFractionalSeconds('F', 3),
+	)
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+
+	testcases := []struct {
+		t        time.Time
+		expected string
+	}{
+		{t: time.Unix(1700000000, 123456789), expected: `1700000000|1700000000123|1700000000123456|1700000000123456789|1700000000.123`},
+		{t: time.Unix(1700000000, 0), expected: `1700000000|1700000000000|1700000000000000|1700000000000000000|1700000000.000`},
+		{t: time.Unix(-2, 500000000), expected: `-2|-1500|-1500000|-1500000000|-1.500`},
+	}
+	for _, tc := range testcases {
+		if !assert.Equal(t, tc.expected, f.FormatString(tc.t), `formatted result matches for %s`, tc.t) {
+			return
+		}
+	}
+
+	f, err = strftime.New(`%F`, strftime.WithUnixFractionalSeconds('F', 0))
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+	if !assert.Equal(t, `1700000000`, f.FormatString(time.Unix(1700000000, 999999999)), `formatted result matches`) {
+		return
+	}
+}
+
+func TestWithCalendar(t *testing.T) {
+	testcases := []struct {
+		calendar strftime.Calendar
+		pattern  string
+		t        time.Time
+		expected string
+	}{
+		{
+			calendar: strftime.JapaneseCalendar(),
+			pattern:  `%{era} %Y (%y) %m/%d %B %j %F`,
+			t:        time.Date(2023, time.March, 3, 0, 0, 0, 0, time.UTC),
+			expected: `Reiwa 5 (05) 03/03 March 062 5-03-03`,
+		},
+		{
+			calendar: strftime.JapaneseCalendar(),
+			pattern:  `%{era} %Y`,
+			t:        time.Date(2019, time.April, 30, 23, 0, 0, 0, time.UTC),
+			expected: `Heisei 31`,
+		},
+		{
+			calendar: strftime.JapaneseCalendar(),
+			pattern:  `%{era} %Y`,
+			t:        time.Date(1989, time.January, 8, 0, 0, 0, 0, time.UTC),
+			expected: `Heisei 1`,
+		},
+		{
+			calendar: strftime.BuddhistCalendar(),
+			pattern:  `%e %B %{era} %Y`,
+			t:        time.Date(2023, time.March, 3, 0, 0, 0, 0, time.UTC),
+			expected: ` 3 March BE 2566`,
+		},
+		{
+			calendar: strftime.MinguoCalendar(),
+			pattern:  `%{era} %Y-%m-%d (%D)`,
+			t:        time.Date(2023, time.March, 3, 0, 0, 0, 0, time.UTC),
+			expected: `ROC 112-03-03 (03/03/12)`,
+		},
+		{
+			calendar: strftime.MinguoCalendar(),
+			pattern:  `%{era} %Y`,
+			t:        time.Date(1900, time.March, 3, 0, 0, 0, 0, time.UTC),
+			expected: `Before ROC 12`,
+		},
+		{
+			calendar: strftime.HijriCalendar(),
+			pattern:  `%-d %B %Y %{era} (%j)`,
+			t:        time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC),
+			expected: `24 Ramadan 1420 AH (260)`,
+		},
+		{
+			calendar: strftime.HijriCalendar(),
+			pattern:  `%F`,
+			t:        time.Date(2023, time.July, 19, 12, 0, 0, 0, time.UTC),
+			expected: `1445-01-01`,
+		},
+		{
+			calendar: strftime.HijriCalendar(),
+			pattern:  `%F (%j)`,
+			t:        time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC),
+			expected: `1410-06-04 (153)`,
+		},
+		{
+			calendar: strftime.TabularHijriCalendar(),
+			pattern:  `%F (%j)`,
+			t:        time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC),
+			expected: `1410-06-03 (151)`,
+		},
+		{
+			calendar: strftime.HijriCalendar(),
+			pattern:  `%d %b %Y|%h|%v|%c`,
+			t:        time.Date(2023, time.July, 19, 12, 0, 0, 0, time.UTC),
+			expected: `01 Muh. 1445|Muh.| 1-Muh.-1445|Wed Muh.  1 12:00:00 1445`,
+		},
+		{
+			calendar: strftime.JapaneseCalendar(),
+			pattern:  `%b|%v|%c`,
+			t:        time.Date(2023, time.March, 3, 4, 5, 6, 0, time.UTC),
+			expected: `Mar| 3-Mar-5|Fri Mar  3 04:05:06 5`,
+		},
+		{
+			// Sha'ban and Ramadan 1364 AH, as given by ICU
+			calendar: strftime.HijriCalendar(),
+			pattern:  `%F`,
+			t:        time.Date(1945, time.July, 11, 0, 0, 0, 0, time.UTC),
+			expected: `1364-08-01`,
+		},
+		{
+			calendar: strftime.HijriCalendar(),
+			pattern:  `%F`,
+			t:        time.Date(1945, time.August, 8, 0, 0, 0, 0, time.UTC),
+			expected: `1364-08-29`,
+		},
+		{
+			calendar: strftime.HijriCalendar(),
+			pattern:  `%F`,
+			t:        time.Date(1945, time.August, 9, 0, 0, 0, 0, time.UTC),
+			expected: `1364-09-01`,
+		},
+		{
+			// outside of the Umm al-Qura table
+			calendar: strftime.HijriCalendar(),
+			pattern:  `%F`,
+			t:        time.Date(2100, time.January, 1, 0, 0, 0, 0, time.UTC),
+			expected: `1523-10-19`,
+		},
+	}
+	for _, tc := range testcases {
+		f, err := strftime.New(tc.pattern, strftime.WithCalendar(tc.calendar))
+		if !assert.NoError(t, err, `strftime.New should succeed`) {
+			return
+		}
+		if !assert.Equal(t, tc.expected, f.FormatString(tc.t), `formatted result matches for %s in the %s calendar`, tc.t, tc.calendar.Name()) {
+			return
+		}
+	}
+
+	// specifications that were replaced by the user are not affected
+	f, err := strftime.New(`%Y/%m`, strftime.WithCalendar(strftime.BuddhistCalendar()), strftime.WithSpecification('m', strftime.Verbatim(`MM`)))
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+	if !assert.Equal(t, `2566/MM`, f.FormatString(time.Date(2023, time.March, 3, 0, 0, 0, 0, time.UTC)), `formatted result matches`) {
+		return
+	}
+
+	_, err = strftime.New(`%{era}`)
+	if !assert.Error(t, err, `%{era} without a calendar should fail`) {
+		return
+	}
+}
+
+func TestAppendUnix(t *testing.T) {
+	newYork, err := time.LoadLocation(`America/New_York`)
+	if !assert.NoError(t, err, `time.LoadLocation should succeed`) {
+		return
+	}
+	locations := []*time.Location{
+		time.UTC,
+		newYork,
+		time.FixedZone(`IST`, 5*60*60+30*60),
+		time.FixedZone(``, -3*60*60),
+	}
+
+	patterns := []string{
+		`%A %a %B %b %C %c %D %d %e %F %H %I %j %k %l %M %m %n %p %R %r %S %T %t %U %u %V %v %W %w %X %x %Y %y %Z %z %%`,
+		`%Y-%m-%dT%H:%M:%S%z`,
+		`%-d/%_m/%5Y %^a %#b`,
+		`%Y%m%d %H%M%S.%L`,
+		`%[.%3f%] %{in:Asia/Tokyo}%H:%M %Z`,
+		`%F %T.%N`,
+	}
+
+	// a fixed seed, so that failures are reproducible
+	var seed uint64 = 0x9e3779b97f4a7c15
+	next := func() uint64 {
+		seed ^= seed << 13
+		seed ^= seed >> 7
+		seed ^= seed << 17
+		return seed
+	}
+
+	// include times around DST transitions and before the epoch
+	var nanos []int64
+	base := time.Date(2023, time.March, 12, 6, 30, 0, 0, time.UTC).UnixNano()
+	for i := int64(0); i < 48; i++ {
+		nanos = append(nanos, base+i*int64(5*time.Minute))
+	}
+	for i := 0; i < 500; i++ {
+		// roughly between 1900 and 2200
+		v := int64(next()%(300*365*24*3600)) - 70*365*24*3600
+		nanos = append(nanos, v*int64(time.Second)+int64(next()%uint64(time.Second)))
+	}
+
+	for _, p := range patterns {
+		f, err := strftime.New(p, strftime.WithMilliseconds('L'), strftime.WithFractionalSeconds('f'), strftime.WithFractionalSeconds('N'))
+		if !assert.NoError(t, err, `strftime.New should succeed`) {
+			return
+		}
+		for _, loc := range locations {
+			out, offsets := f.AppendUnixNanos(nil, nanos, loc)
+			if !assert.Len(t, offsets, len(nanos)+1, `offsets should have one more element than the input`) {
+				return
+			}
+			for i, v := range nanos {
+				tm := time.Unix(0, v).In(loc)
+				expected := f.FormatString(tm)
+				if !assert.Equal(t, expected, string(f.AppendUnix(nil, tm.Unix(), int64(tm.Nanosecond()), loc)), `AppendUnix result matches for %q at %s`, p, tm) {
+					return
+				}
+				if !assert.Equal(t, expected, string(out[offsets[i]:offsets[i+1]]), `AppendUnixNanos result matches for %q at %s`, p, tm) {
+					return
+				}
+			}
+		}
+	}
+
+	// unnormalized nanoseconds, and the default location
+	f, err := strftime.New(`%F %T.%L`, strftime.WithMilliseconds('L'))
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+	if !assert.Equal(t, `1969-12-31 23:59:58.500`, string(f.AppendUnix(nil, 0, -1500*int64(time.Millisecond), nil)), `AppendUnix result matches`) {
+		return
+	}
+}
+
+func TestFormatAll(t *testing.T) {
+	f, err := strftime.New(`%Y-%m-%d %H:%M:%S %A`)
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+
+	ts := make([]time.Time, 10000)
+	for i := range ts {
+		ts[i] = ref.Add(time.Duration(i) * 37 * time.Hour)
+	}
+
+	prefix := []byte(`prefix:`)
+	out, offsets := f.FormatAll(append([]byte(nil), prefix...), ts)
+	if !assert.Len(t, offsets, len(ts)+1, `offsets should have one more element than the input`) {
+		return
+	}
+	if !assert.Equal(t, prefix, out[:offsets[0]], `dst is preserved`) {
+		return
+	}
+	for i, tm := range ts {
+		if !assert.Equal(t, f.FormatString(tm), string(out[offsets[i]:offsets[i+1]]), `formatted result matches`) {
+			return
+		}
+	}
+
+	for _, n := range []int{0, 1, 3, 8, 100} {
+		pout, poffsets := f.FormatAllParallel(append([]byte(nil), prefix...), ts, n)
+		if !assert.Equal(t, out, pout, `FormatAllParallel output matches FormatAll (n = %d)`, n) {
+			return
+		}
+		if !assert.Equal(t, offsets, poffsets, `FormatAllParallel offsets match FormatAll (n = %d)`, n) {
+			return
+		}
+	}
+
+	// n close to len(ts) / 1024, where rounding up the chunk size used to
+	// leave the last chunks past the end of ts
+	many := make([]time.Time, 3072001)
+	out, offsets = f.FormatAll(nil, many)
+	pout, poffsets := f.FormatAllParallel(nil, many, 3000)
+	if !assert.Equal(t, out, pout, `FormatAllParallel output matches FormatAll for large n`) {
+		return
+	}
+	if !assert.Equal(t, offsets, poffsets, `FormatAllParallel offsets match FormatAll for large n`) {
+		return
+	}
+
+	out, offsets = f.FormatAll(nil, nil)
+	if !assert.Empty(t, out, `output is empty`) {
+		return
+	}
+	if !assert.Equal(t, []int{0}, offsets, `offsets has a single element`) {
+		return
+	}
+}
+
+func TestParse(t *testing.T) {
+	jst, err := time.LoadLocation(`Asia/Tokyo`)
+	if !assert.NoError(t, err, `time.LoadLocation should succeed`) {
+		return
+	}
+
+	testcases := []struct {
+		Pattern  string
+		Input    string
+		Options  []strftime.Option
+		Expected time.Time
+	}{
+		{`%Y-%m-%dT%H:%M:%S%z`, `2006-01-02T15:04:05+0900`, nil, time.Date(2006, 1, 2, 15, 4, 5, 0, time.FixedZone(``, 9*3600))},
+		{`%d/%b/%Y:%H:%M:%S %z`, `02/Jan/2006:15:04:05 -0700`, nil, time.Date(2006, 1, 2, 15, 4, 5, 0, time.FixedZone(``, -7*3600))},
+		{`%c`, `Mon Jan  2 15:04:05 2006`, nil, time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{`%D %r`, `01/02/06 03:04:05 PM`, nil, time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{`%B %e, %Y`, `january  2, 2006`, []strftime.Option{strftime.WithLocation(jst)}, time.Date(2006, 1, 2, 0, 0, 0, 0, jst)},
+		{`%Y %j`, `2024 366`, nil, time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)},
+		{`%-d/%-m/%y`, `2/1/68`, nil, time.Date(2068, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{`%-d/%-m/%y`, `2/1/69`, nil, time.Date(1969, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{`%T%[.%L%]`, `15:04:05.123`, []strftime.Option{strftime.WithMilliseconds('L')}, time.Date(0, 1, 1, 15, 4, 5, 123000000, time.UTC)},
+		{`%T%[.%L%]`, `15:04:05`, []strftime.Option{strftime.WithMilliseconds('L')}, time.Date(0, 1, 1, 15, 4, 5, 0, time.UTC)},
+		{`%s`, `1136239445`, []strftime.Option{strftime.WithUnixSeconds('s')}, time.Unix(1136239445, 0).UTC()},
+		{`%s`, `1136239445.123`, []strftime.Option{strftime.WithUnixFractionalSeconds('s', 3)}, time.Unix(1136239445, 123000000).UTC()},
+		{`%s`, `9223372036854775807`, []strftime.Option{strftime.WithUnixNanoseconds('s')}, time.Unix(0, math.MaxInt64).UTC()},
+		{`%F %Z`, `2006-01-02 Asia/Tokyo`, []strftime.Option{strftime.WithZoneName('Z')}, time.Date(2006, 1, 2, 0, 0, 0, 0, jst)},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Pattern+`/`+tc.Input, func(t *testing.T) {
+			got, err := strftime.Parse(tc.Pattern, tc.Input, tc.Options...)
+			if !assert.NoError(t, err, `strftime.Parse should succeed`) {
+				return
+			}
+			if !assert.True(t, tc.Expected.Equal(got), `parsed time matches (expected %s, got %s)`, tc.Expected, got) {
+				return
+			}
+			_, expectedOffset := tc.Expected.Zone()
+			_, offset := got.Zone()
+			if !assert.Equal(t, expectedOffset, offset, `offsets match`) {
+				return
+			}
+		})
+	}
+
+	// round trip
+	f, err := strftime.New(`%Y-%m-%d %H:%M:%S.%L %z %Z`, strftime.WithMilliseconds('L'), strftime.WithLocation(jst))
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+	expected := ref.Truncate(time.Millisecond)
+	got, err := f.Parse(f.FormatString(expected))
+	if !assert.NoError(t, err, `Parse should succeed`) {
+		return
+	}
+	if !assert.Equal(t, f.FormatString(expected), f.FormatString(got), `round trip succeeds`) {
+		return
+	}
+	if !assert.Equal(t, `Asia/Tokyo`, got.Location().String(), `location is the default location`) {
+		return
+	}
+
+	for _, tc := range []struct {
+		Pattern string
+		Input   string
+	}{
+		{`%Y-%m-%d`, `2006-02-30`},
+		{`%Y-%m-%d`, `2006-13-01`},
+		{`%H:%M`, `24:00`},
+		{`%I %p`, `13 PM`},
+		{`%Y-%m-%d`, `2006-01-02 extra`},
+		{`%Y-%m-%d`, `2006/01/02`},
+	} {
+		if !assert.Error(t, func() error { _, err := strftime.Parse(tc.Pattern, tc.Input); return err }(), `Parse should fail for %q`, tc.Input) {
+			return
+		}
+	}
+
+	for _, input := range []string{`9223372036854775808`, `9999999999999999999`, `-9999999999999999999`} {
+		_, err = strftime.Parse(`%s`, input, strftime.WithUnixNanoseconds('s'))
+		if !assert.Error(t, err, `Parse should fail for %q, which overflows int64`, input) {
+			return
+		}
+	}
+
+	_, err = strftime.Parse(`%{ago}`, `just now`)
+	if !assert.Error(t, err, `named specifications cannot be parsed`) {
+		return
+	}
+	_, err = strftime.Parse(`%Y`, `2006`, strftime.WithCalendar(strftime.BuddhistCalendar()))
+	if !assert.Error(t, err, `calendars cannot be parsed`) {
+		return
+	}
+}
+
+func TestRewriter(t *testing.T) {
+	from, err := strftime.New(`%d/%b/%Y:%H:%M:%S %z`)
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+	to, err := strftime.New(`%Y-%m-%dT%H:%M:%S%z`)
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+
+	const input = "02/Jan/2006:15:04:05 -0700 GET /\n" +
+		"not a timestamp\n" +
+		"127.0.0.1 [03/Jan/2006:15:04:05 -0700] GET /\n" +
+		"02/Jan/2006:15:04:05 -0700"
+
+	testcases := []struct {
+		Name     string
+		Options  []strftime.Option
+		Expected string
+	}{
+		{
+			Name: `line start`,
+			Expected: "2006-01-02T15:04:05-0700 GET /\n" +
+				"not a timestamp\n" +
+				"127.0.0.1 [03/Jan/2006:15:04:05 -0700] GET /\n" +
+				"2006-01-02T15:04:05-0700",
+		},
+		{
+			Name:    `anywhere`,
+			Options: []strftime.Option{strftime.WithRewriteMode(strftime.RewriteAnywhere)},
+			Expected: "2006-01-02T15:04:05-0700 GET /\n" +
+				"not a timestamp\n" +
+				"127.0.0.1 [2006-01-03T15:04:05-0700] GET /\n" +
+				"2006-01-02T15:04:05-0700",
+		},
+		{
+			Name:    `to UTC`,
+			Options: []strftime.Option{strftime.WithRewriteMode(strftime.RewriteAnywhere), strftime.WithUTC()},
+			Expected: "2006-01-02T22:04:05+0000 GET /\n" +
+				"not a timestamp\n" +
+				"127.0.0.1 [2006-01-03T22:04:05+0000] GET /\n" +
+				"2006-01-02T22:04:05+0000",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			rw, err := strftime.NewRewriter(bytes.NewBufferString(input), from, to, tc.Options...)
+			if !assert.NoError(t, err, `strftime.NewRewriter should succeed`) {
+				return
+			}
+			var out bytes.Buffer
+			if !assert.NoError(t, func() error { _, err := out.ReadFrom(rw); return err }(), `reading should succeed`) {
+				return
+			}
+			if !assert.Equal(t, tc.Expected, out.String(), `rewritten output matches`) {
+				return
+			}
+		})
+	}
+
+	// timestamps must not be part of a longer word
+	from, err = strftime.New(`%Y%m%d`)
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+	to, err = strftime.New(`%F`)
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+	rw, err := strftime.NewRewriter(bytes.NewBufferString("id=x20060102 date=20060102 n=2006010299"), from, to, strftime.WithRewriteMode(strftime.RewriteAnywhere))
+	if !assert.NoError(t, err, `strftime.NewRewriter should succeed`) {
+		return
+	}
+	var out bytes.Buffer
+	if _, err := out.ReadFrom(rw); !assert.NoError(t, err, `reading should succeed`) {
+		return
+	}
+	if !assert.Equal(t, "id=x20060102 date=2006-01-02 n=2006010299", out.String(), `only whole words are rewritten`) {
+		return
+	}
+
+	// lines that are longer than the read buffer are rewritten as a whole
+	padding := string(bytes.Repeat([]byte{'.'}, 100000))
+	rw, err = strftime.NewRewriter(bytes.NewBufferString("20060102 "+padding+" 20060103\n"+padding+"20060104\n20060105"), from, to, strftime.WithRewriteMode(strftime.RewriteAnywhere))
+	if !assert.NoError(t, err, `strftime.NewRewriter should succeed`) {
+		return
+	}
+	out.Reset()
+	if _, err := out.ReadFrom(rw); !assert.NoError(t, err, `reading should succeed`) {
+		return
+	}
+	if !assert.Equal(t, "2006-01-02 "+padding+" 2006-01-03\n"+padding+"2006-01-04\n2006-01-05", out.String(), `long lines are rewritten`) {
+		return
+	}
+}
+
+func TestFindAll(t *testing.T) {
+	f, err := strftime.New(`%Y-%m-%d %H:%M:%S%[.%L%]`, strftime.WithMilliseconds('L'))
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+
+	text := []byte("alert at 2006-01-02 15:04:05.123, cleared at 2006-01-02 15:09:00 (id 12006-01-02 15:04:05)\n" +
+		"invalid: 2006-02-30 00:00:00\n" +
+		"[2006-01-03 00:00:00]")
+
+	matches := f.FindAll(text, -1)
+	expected := []time.Time{
+		time.Date(2006, 1, 2, 15, 4, 5, 123000000, time.UTC),
+		time.Date(2006, 1, 2, 15, 9, 0, 0, time.UTC),
+		time.Date(2006, 1, 3, 0, 0, 0, 0, time.UTC),
+	}
+	if !assert.Len(t, matches, len(expected), `number of matches`) {
+		return
+	}
+	for i, m := range matches {
+		if !assert.True(t, expected[i].Equal(m.Time), `time matches (expected %s, got %s)`, expected[i], m.Time) {
+			return
+		}
+		if !assert.Equal(t, f.FormatString(m.Time), string(text[m.Start:m.End]), `span matches`) {
+			return
+		}
+	}
+
+	if !assert.Len(t, f.FindAll(text, 2), 2, `at most n matches are returned`) {
+		return
+	}
+	if !assert.Nil(t, f.FindAll(text, 0), `no matches are returned for n = 0`) {
+		return
+	}
+	if !assert.Nil(t, f.FindAll([]byte(`nothing here`), -1), `no matches`) {
+		return
+	}
+}
+
+func TestInfer(t *testing.T) {
+	testcases := []struct {
+		Samples  []string
+		Expected []string
+		// no pattern formats the samples as they were
+		ParseOnly bool
+	}{
+		{
+			Samples:  []string{`02/Jan/2006:15:04:05 -0700`, `13/Feb/2007:01:02:03 +0900`},
+			Expected: []string{`%d/%b/%Y:%H:%M:%S %z`},
+		},
+		{
+			Samples:  []string{`01/02/2006 03:04:05 PM`},
+			Expected: []string{`%m/%d/%Y %I:%M:%S %p`, `%d/%m/%Y %I:%M:%S %p`},
+		},
+		{
+			Samples:  []string{`01/02/2006 03:04:05 PM`, `12/31/2006 03:04:05 AM`},
+			Expected: []string{`%m/%d/%Y %I:%M:%S %p`},
+		},
+		{
+			Samples:  []string{`13.01.2006`, `02.01.2006`},
+			Expected: []string{`%d.%m.%Y`},
+		},
+		{
+			Samples:   []string{`2006-01-02T15:04:05.123Z`, `2006-01-13T15:04:05.456+09:00`},
+			Expected:  []string{`%Y-%m-%dT%H:%M:%S.%3N%z`},
+			ParseOnly: true,
+		},
+		{
+			Samples:  []string{`2006-01-02T15:04:05Z`, `2006-01-13T01:02:03Z`},
+			Expected: []string{`%Y-%m-%dT%H:%M:%SZ`, `%Y-%m-%dT%H:%M:%S%z`},
+		},
+		{
+			Samples:  []string{`Jan  2 15:04:05`, `Jan 12 15:04:05`},
+			Expected: []string{`%b %e %H:%M:%S`},
+		},
+		{
+			Samples:  []string{`Mon, 02 Jan 2006 15:04:05 MST`},
+			Expected: []string{`%a, %d %b %Y %H:%M:%S %Z`, `%a, %d %b %Y %H:%M:%S MST`},
+		},
+		{
+			Samples:  []string{`1136239445`},
+			Expected: []string{`%s`},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Samples[0], func(t *testing.T) {
+			candidates, err := strftime.Infer(tc.Samples)
+			if !assert.NoError(t, err, `strftime.Infer should succeed`) {
+				return
+			}
+			var patterns []string
+			var total float64
+			for _, c := range candidates {
+				patterns = append(patterns, c.Pattern)
+				total += c.Confidence
+
+				// every candidate parses all of the samples
+				f, err := strftime.New(c.Pattern, c.Options...)
+				if !assert.NoError(t, err, `strftime.New should succeed for %q`, c.Pattern) {
+					return
+				}
+				for _, sample := range tc.Samples {
+					if _, err := f.Parse(sample); !assert.NoError(t, err, `%q should parse %q`, c.Pattern, sample) {
+						return
+					}
+				}
+			}
+			if !assert.Equal(t, tc.Expected, patterns, `candidates match`) {
+				return
+			}
+			if !assert.InDelta(t, 1, total, 1e-9, `confidences add up to 1`) {
+				return
+			}
+			if tc.ParseOnly {
+				return
+			}
+
+			// the top candidate formats the samples as they were
+			f, err := strftime.New(candidates[0].Pattern, candidates[0].Options...)
+			if !assert.NoError(t, err, `strftime.New should succeed`) {
+				return
+			}
+			for _, sample := range tc.Samples {
+				parsed, err := f.Parse(sample)
+				if !assert.NoError(t, err, `Parse should succeed`) {
+					return
+				}
+				if !assert.Equal(t, sample, f.FormatString(parsed), `%q formats %q as it was`, candidates[0].Pattern, sample) {
+					return
+				}
+			}
+		})
+	}
+
+	for _, samples := range [][]string{nil, {`hello`}, {`2006-01-02`, `Jan 2 2006`}} {
+		if _, err := strftime.Infer(samples); !assert.Error(t, err, `strftime.Infer should fail for %q`, samples) {
+			return
+		}
+	}
+}
+
+// mapSpecificationSet is a SpecificationSet implemented outside of the
+// package, which only has the methods of the interface
+type mapSpecificationSet map[byte]strftime.Appender
+
+func (ds mapSpecificationSet) Lookup(c byte) (strftime.Appender, error) {
+	a, ok := ds[c]
+	if !ok {
+		return nil, fmt.Errorf(`'%c' was not found`, c)
+	}
+	return a, nil
+}
+
+func (ds mapSpecificationSet) Delete(c byte) error {
+	delete(ds, c)
+	return nil
+}
+
+func (ds mapSpecificationSet) Set(c byte, a strftime.Appender) error {
+	ds[c] = a
+	return nil
+}
+
+func TestCustomSpecificationSet(t *testing.T) {
+	custom := mapSpecificationSet{'Y': strftime.Verbatim(`year`)}
+	if !assert.Equal(t, []byte{'Y'}, strftime.SpecificationSetKeys(custom), `keys are found by looking up each character`) {
+		return
+	}
+
+	clone := strftime.CloneSpecificationSet(custom)
+	if !assert.NoError(t, clone.Set('L', strftime.Milliseconds()), `clone is mutable`) {
+		return
+	}
+	if !assert.Equal(t, []byte{'Y'}, strftime.SpecificationSetKeys(custom), `custom set is unaffected by its clone`) {
+		return
+	}
+
+	if !assert.Error(t, strftime.MergeSpecificationSet(custom, strftime.NewSpecificationSet(), strftime.MergeFailOnConflict), `Merge should fail on conflicts`) {
+		return
+	}
+	if !assert.Len(t, custom, 1, `custom set is unchanged after a failed Merge`) {
+		return
+	}
+	if !assert.NoError(t, strftime.MergeSpecificationSet(custom, strftime.NewSpecificationSet(), strftime.MergeKeepExisting), `Merge should succeed`) {
+		return
+	}
+	if !assert.Len(t, custom, 40, `specifications are added to the custom set`) {
+		return
+	}
+
+	f, err := strftime.New(`%Y %m`, strftime.WithSpecificationSet(custom))
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+	if !assert.Equal(t, `year 01`, f.FormatString(ref), `existing specification is kept`) {
+		return
+	}
+}
+
+func TestSpecificationSetMethods(t *testing.T) {
+	def := strftime.DefaultSpecificationSet()
+	if !assert.Error(t, def.Set('L', strftime.Milliseconds()), `default set is immutable`) {
+		return
+	}
+	if !assert.Len(t, strftime.SpecificationSetKeys(def), 40, `default set has all default specifications`) {
+		return
+	}
+
+	ss := strftime.CloneSpecificationSet(def)
+	if !assert.Equal(t, strftime.SpecificationSetKeys(def), strftime.SpecificationSetKeys(ss), `clone has the same keys`) {
+		return
+	}
+	if !assert.NoError(t, ss.Set('L', strftime.Milliseconds()), `clone is mutable`) {
+		return
+	}
+	if !assert.NotContains(t, strftime.SpecificationSetKeys(def), byte('L'), `default set is unaffected`) {
+		return
+	}
+
+	var visited []byte
+	strftime.RangeSpecificationSet(ss, func(c byte, _ strftime.Appender) bool {
+		visited = append(visited, c)
+		return c < 'C'
+	})
+	if !assert.Equal(t, []byte{'%', 'A', 'B', 'C'}, visited, `Range visits keys in order, and stops when asked to`) {
+		return
+	}
+
+	team := strftime.NewSpecificationSet()
+	if !assert.NoError(t, team.Set('L', strftime.Microseconds()), `Set should succeed`) {
+		return
+	}
+	if !assert.NoError(t, team.Set('s', strftime.UnixSeconds()), `Set should succeed`) {
+		return
+	}
+
+	lookup := func(ds strftime.SpecificationSet, c byte) strftime.Appender {
+		var found strftime.Appender
+		strftime.RangeSpecificationSet(ds, func(k byte, a strftime.Appender) bool {
+			if k == c {
+				found = a
+			}
+			return true
+		})
+		return found
+	}
+
+	kept := strftime.CloneSpecificationSet(ss)
+	if !assert.NoError(t, strftime.MergeSpecificationSet(kept, team, strftime.MergeKeepExisting), `Merge should succeed`) {
+		return
+	}
+	if !assert.Equal(t, strftime.Milliseconds(), lookup(kept, 'L'), `existing specification is kept`) {
+		return
+	}
+	if !assert.NotNil(t, lookup(kept, 's'), `new specification is added`) {
+		return
+	}
+
+	overwritten := strftime.CloneSpecificationSet(ss)
+	if !assert.NoError(t, strftime.MergeSpecificationSet(overwritten, team, strftime.MergeOverwrite), `Merge should succeed`) {
+		return
+	}
+	if !assert.Equal(t, strftime.Microseconds(), lookup(overwritten, 'L'), `existing specification is overwritten`) {
+		return
+	}
+
+	failed := strftime.CloneSpecificationSet(ss)
+	if !assert.Error(t, strftime.MergeSpecificationSet(failed, team, strftime.MergeFailOnConflict), `Merge should fail on conflicts`) {
+		return
+	}
+	if !assert.Nil(t, lookup(failed, 's'), `set is unchanged after a failed Merge`) {
+		return
+	}
+	if !assert.NoError(t, strftime.MergeSpecificationSet(failed, def, strftime.MergeFailOnConflict), `identical specifications do not conflict`) {
+		return
+	}
+
+	if !assert.Error(t, strftime.MergeSpecificationSet(def, team, strftime.MergeOverwrite), `default set cannot be merged into`) {
+		return
+	}
+}
+
+func TestFreezeAndOverlay(t *testing.T) {
+	ss := strftime.NewSpecificationSet()
+	if !assert.NoError(t, ss.Set('L', strftime.Milliseconds()), `Set should succeed before Freeze`) {
+		return
+	}
+	if !assert.NoError(t, strftime.FreezeSpecificationSet(ss), `FreezeSpecificationSet should succeed`) {
+		return
+	}
+	if !assert.Error(t, ss.Set('s', strftime.UnixSeconds()), `Set should fail after Freeze`) {
+		return
+	}
+	if !assert.Error(t, ss.Delete('L'), `Delete should fail after Freeze`) {
+		return
+	}
+	if !assert.Error(t, strftime.MergeSpecificationSet(ss, strftime.NewSpecificationSet(), strftime.MergeOverwrite), `Merge should fail after Freeze`) {
+		return
+	}
+	if !assert.NoError(t, strftime.FreezeSpecificationSet(ss), `freezing twice is harmless`) {
+		return
+	}
+
+	a, err := ss.Lookup('L')
+	if !assert.NoError(t, err, `Lookup should succeed on a frozen set`) {
+		return
+	}
+	if !assert.Equal(t, strftime.Milliseconds(), a, `Lookup returns the specification`) {
+		return
+	}
+
+	overlay := strftime.NewOverlaySpecificationSet(ss, map[byte]strftime.Appender{
+		's': strftime.UnixSeconds(),
+		'L': strftime.Microseconds(),
+	})
+	if !assert.Error(t, overlay.Set('Q', strftime.Milliseconds()), `overlays are immutable`) {
+		return
+	}
+	if !assert.Contains(t, strftime.SpecificationSetKeys(overlay), byte('s'), `overlay has the extra specifications`) {
+		return
+	}
+	if !assert.Contains(t, strftime.SpecificationSetKeys(overlay), byte('Y'), `overlay has the base specifications`) {
+		return
+	}
+	if !assert.NotContains(t, strftime.SpecificationSetKeys(ss), byte('s'), `base is unaffected`) {
+		return
+	}
+
+	f, err := strftime.New(`%Y %L %s`, strftime.WithSpecificationSet(overlay))
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+	if !assert.Equal(t, `2006 123456 1136239445`, f.FormatString(ref), `extra specifications take precedence`) {
+		return
+	}
+
+	clone := strftime.CloneSpecificationSet(overlay)
+	if !assert.NoError(t, clone.Set('Q', strftime.Milliseconds()), `clones of overlays are mutable`) {
+		return
+	}
+	if !assert.NotContains(t, strftime.SpecificationSetKeys(overlay), byte('Q'), `overlay is unaffected by its clone`) {
+		return
+	}
+
+	mutable := strftime.NewSpecificationSet()
+	snapshot := strftime.NewOverlaySpecificationSet(mutable, map[byte]strftime.Appender{
+		's': strftime.UnixSeconds(),
+	})
+	if !assert.NoError(t, mutable.Set('L', strftime.Milliseconds()), `the base of an overlay is not frozen`) {
+		return
+	}
+	if !assert.NotContains(t, strftime.SpecificationSetKeys(snapshot), byte('L'), `overlay is unaffected by changes to a mutable base`) {
+		return
+	}
+
+	if !assert.Error(t, strftime.FreezeSpecificationSet(mapSpecificationSet{}), `sets without Freeze cannot be frozen`) {
+		return
+	}
+
+	// WithSpecification layers over the given frozen set
+	f, err = strftime.New(`%L %s`, strftime.WithSpecificationSet(ss), strftime.WithUnixSeconds('s'))
+	if !assert.NoError(t, err, `strftime.New should succeed`) {
+		return
+	}
+	if !assert.Equal(t, `123 1136239445`, f.FormatString(ref), `WithSpecification works with frozen sets`) {
+		return
+	}
+}
+
+func TestSpecificationSetConcurrency(t *testing.T) {
+	t.Run("Set after Lookup", func(t *testing.T) {
+		ss := strftime.NewSpecificationSet()
+		if _, err := ss.Lookup('Y'); !assert.NoError(t, err, `Lookup should succeed`) {
+			return
+		}
+
+		done := make(chan error)
+		go func() {
+			done <- ss.Set('L', strftime.Milliseconds())
+		}()
+		select {
+		case err := <-done:
+			if !assert.NoError(t, err, `Set should succeed`) {
+				return
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal(`Set after Lookup deadlocked`)
+		}
+	})
+	t.Run("Set, Delete and Lookup", func(t *testing.T) {
+		ss := strftime.NewSpecificationSet()
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 1000; j++ {
+					c := byte('0' + (i*1000+j)%10)
+					_ = ss.Set(c, strftime.Milliseconds())
+					_ = ss.Delete(c)
+				}
+			}(i)
+		}
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 1000; j++ {
+					if _, err := ss.Lookup('Y'); err != nil {
+						t.Errorf(`Lookup should succeed: %s`, err)
+						return
+					}
+					_, _ = ss.Lookup('0')
+					_ = strftime.SpecificationSetKeys(ss)
+					strftime.RangeSpecificationSet(ss, func(byte, strftime.Appender) bool { return true })
+				}
+			}()
+		}
+		wg.Wait()
+	})
+	t.Run("New with shared sets", func(t *testing.T) {
+		ss := strftime.NewSpecificationSet()
+		if !assert.NoError(t, ss.Set('L', strftime.Milliseconds()), `Set should succeed`) {
+			return
+		}
+		frozen := strftime.CloneSpecificationSet(ss)
+		if !assert.NoError(t, strftime.FreezeSpecificationSet(frozen), `FreezeSpecificationSet should succeed`) {
+			return
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				_ = ss.Set('s', strftime.UnixSeconds())
+				_ = ss.Delete('s')
+			}
+		}()
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 200; j++ {
+					for _, ds := range []strftime.SpecificationSet{ss, frozen} {
+						f, err := strftime.New(`%Y-%m-%d %H:%M:%S.%L`, strftime.WithSpecificationSet(ds))
+						if err != nil {
+							t.Errorf(`strftime.New should succeed: %s`, err)
+							return
+						}
+						if got := f.FormatString(ref); got != `2006-01-02 22:04:05.123` {
+							t.Errorf(`unexpected result %q`, got)
+							return
+						}
+					}
+					if _, err := strftime.New(`%s`, strftime.WithSpecificationSet(frozen), strftime.WithUnixSeconds('s')); err != nil {
+						t.Errorf(`strftime.New should succeed: %s`, err)
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	})
+	t.Run("Freeze while writing", func(t *testing.T) {
+		ss := strftime.NewSpecificationSet()
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 1000; j++ {
+					_ = ss.Set('L', strftime.Milliseconds())
+					_, _ = ss.Lookup('L')
+				}
+			}()
+		}
+		if !assert.NoError(t, strftime.FreezeSpecificationSet(ss), `FreezeSpecificationSet should succeed`) {
+			return
+		}
+		keys := strftime.SpecificationSetKeys(ss)
+		wg.Wait()
+		if !assert.Equal(t, keys, strftime.SpecificationSetKeys(ss), `frozen set does not change`) {
+			return
+		}
+		if !assert.Error(t, ss.Set('L', strftime.Milliseconds()), `Set should fail after Freeze`) {
+			return
+		}
+	})
+}
@@ -0,0 +1,300 @@
+package strftime
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TokenKind describes what a Token represents
+type TokenKind int
+
+const (
+	// TokenVerbatim is a piece of static text
+	TokenVerbatim TokenKind = iota + 1
+	// TokenSpecification is a conversion specification such as `%Y`,
+	// including any flags, width, and modifier (e.g. `%-d`, `%_5H`, `%Ey`)
+	TokenSpecification
+	// TokenNamed is a named specification such as `%{name}` or
+	// `%{name:argument}`
+	TokenNamed
+	// TokenOptionalStart (`%[`) starts an optional section, which is
+	// omitted if all of the Appenders within it report a zero value
+	TokenOptionalStart
+	// TokenOptionalEnd (`%]`) ends an optional section
+	TokenOptionalEnd
+)
+
+func (k TokenKind) String() string {
+	switch k {
+	case TokenVerbatim:
+		return "verbatim"
+	case TokenSpecification:
+		return "specification"
+	case TokenNamed:
+		return "named"
+	case TokenOptionalStart:
+		return "optional-start"
+	case TokenOptionalEnd:
+		return "optional-end"
+	default:
+		return fmt.Sprintf("TokenKind(%d)", int(k))
+	}
+}
+
+// Token is a single element of a strftime pattern, as returned by Tokenize.
+//
+// Start and End denote the byte span of the token within the pattern,
+// such that `pattern[tok.Start:tok.End] == tok.Text`. Tokens created by
+// hand may leave them empty.
+type Token struct {
+	Kind  TokenKind
+	Start int
+	End   int
+	// Text is the raw text of the token as it appears in the pattern.
+	// For TokenVerbatim, this is the static text itself.
+	Text string
+
+	// The following fields are only valid for TokenSpecification
+
+	// Specification is the conversion character, e.g. 'Y' for `%Y`
+	Specification byte
+	// Flags holds the flag characters given in the specification, in the
+	// order they appeared. Each is one of '-' (do not pad), '_' (pad with
+	// spaces), '0' (pad with zeros), '^' (convert to upper case), or '#'
+	// (swap case).
+	Flags string
+	// Width is the minimum field width, or 0 if not specified
+	Width int
+	// Modifier is either 'E' (alternative representation) or 'O'
+	// (alternative numeric symbols), or 0 if not specified. As only the
+	// C locale is supported, modifiers are accepted but otherwise ignored.
+	Modifier byte
+
+	// The following fields are only valid for TokenNamed
+
+	// Name is the name of the named specification, e.g. "foo" for `%{foo:bar}`
+	Name string
+	// Argument is the optional argument, e.g. "bar" for `%{foo:bar}`
+	Argument string
+}
+
+// String returns the textual representation of the token, suitable for
+// use as (part of) a pattern.
+func (tok Token) String() string {
+	switch tok.Kind {
+	case TokenVerbatim:
+		return strings.ReplaceAll(tok.Text, "%", "%%")
+	case TokenSpecification:
+		var sb strings.Builder
+		sb.WriteByte('%')
+		sb.WriteString(tok.Flags)
+		if tok.Width > 0 {
+			sb.WriteString(strconv.Itoa(tok.Width))
+		}
+		if tok.Modifier != 0 {
+			sb.WriteByte(tok.Modifier)
+		}
+		sb.WriteByte(tok.Specification)
+		return sb.String()
+	case TokenNamed:
+		if tok.Argument != "" {
+			return "%{" + tok.Name + ":" + tok.Argument + "}"
+		}
+		return "%{" + tok.Name + "}"
+	case TokenOptionalStart:
+		return "%["
+	case TokenOptionalEnd:
+		return "%]"
+	default:
+		return tok.Text
+	}
+}
+
+const maxTokenWidth = 1024
+
+func isFlag(c byte) bool {
+	switch c {
+	case '-', '_', '0', '^', '#':
+		return true
+	}
+	return false
+}
+
+// modifiers are only recognized before the conversion characters that
+// POSIX allows them for. Otherwise, `E` and `O` are treated as regular
+// conversion characters, so that custom specifications using them keep
+// working.
+func isModifierFor(m, c byte) bool {
+	switch m {
+	case 'E':
+		return strings.IndexByte("cCxXyY", c) >= 0
+	case 'O':
+		return strings.IndexByte("deHImMSuUVwWy", c) >= 0
+	}
+	return false
+}
+
+// isGrammarChar reports if c has a special meaning right after `%`, as a
+// flag, a width, or the start of a named specification or an optional
+// section
+func isGrammarChar(c byte) bool {
+	return isFlag(c) || (c >= '0' && c <= '9') || c == '{' || c == '[' || c == ']'
+}
+
+// nextToken extracts a single token from p, starting at offset pos.
+// It is the one and only implementation of the pattern grammar, and is
+// shared by the compiler and Tokenize.
+//
+// Characters that are registered as specifications (as reported by
+// `registered`, which may be nil) keep their meaning as conversion
+// characters, even if they are also flags or the start of other
+// constructs. This keeps patterns written before flags were supported,
+// such as `%_` for a custom specification on '_', working as they did
+func nextToken(p string, pos int, registered func(byte) bool) (Token, error) {
+	s := p[pos:]
+	i := strings.IndexByte(s, '%')
+	if i < 0 {
+		i = len(s)
+	}
+	if i > 0 {
+		return Token{Kind: TokenVerbatim, Start: pos, End: pos + i, Text: s[:i]}, nil
+	}
+
+	l := len(s)
+	if l == 1 {
+		return Token{}, errors.New(`stray % at the end of pattern`)
+	}
+
+	if registered != nil && isGrammarChar(s[1]) && registered(s[1]) {
+		return Token{
+			Kind:          TokenSpecification,
+			Start:         pos,
+			End:           pos + 2,
+			Text:          s[:2],
+			Specification: s[1],
+		}, nil
+	}
+
+	switch s[1] {
+	case '[':
+		return Token{Kind: TokenOptionalStart, Start: pos, End: pos + 2, Text: s[:2]}, nil
+	case ']':
+		return Token{Kind: TokenOptionalEnd, Start: pos, End: pos + 2, Text: s[:2]}, nil
+	case '{':
+		end := strings.IndexByte(s, '}')
+		if end < 0 {
+			return Token{}, fmt.Errorf(`unterminated named specification at offset %d`, pos)
+		}
+		name, arg, _ := strings.Cut(s[2:end], ":")
+		if name == "" {
+			return Token{}, fmt.Errorf(`empty named specification at offset %d`, pos)
+		}
+		return Token{
+			Kind:     TokenNamed,
+			Start:    pos,
+			End:      pos + end + 1,
+			Text:     s[:end+1],
+			Name:     name,
+			Argument: arg,
+		}, nil
+	}
+
+	// %[flags][width][modifier]conversion
+	tok := Token{Kind: TokenSpecification, Start: pos}
+	j := 1
+	for j < l-1 && isFlag(s[j]) {
+		j++
+	}
+	tok.Flags = s[1:j]
+
+	widthStart := j
+	for j < l-1 && s[j] >= '0' && s[j] <= '9' {
+		j++
+	}
+	if j > widthStart {
+		width, err := strconv.Atoi(s[widthStart:j])
+		if err != nil || width > maxTokenWidth {
+			return Token{}, fmt.Errorf(`invalid width %q at offset %d`, s[widthStart:j], pos)
+		}
+		tok.Width = width
+	}
+
+	// a modifier character that is registered as a specification is
+	// the conversion character instead
+	if j < l-1 && isModifierFor(s[j], s[j+1]) && (registered == nil || !registered(s[j])) {
+		tok.Modifier = s[j]
+		j++
+	}
+
+	tok.Specification = s[j]
+	tok.End = pos + j + 1
+	tok.Text = s[:j+1]
+	return tok, nil
+}
+
+// Tokenize splits the pattern into tokens, using the exact same grammar
+// that is used by New and Format. This allows external tools to inspect
+// patterns without re-implementing the parser.
+//
+// Tokenize only checks the syntax of the pattern: it does not check if
+// the specifications actually exist in any specification set.
+//
+// As with the default specification set, flag characters, digits, `{`,
+// `[` and `]` are never treated as conversion characters.
+func Tokenize(p string) ([]Token, error) {
+	return tokenize(p, nil)
+}
+
+func tokenize(p string, registered func(byte) bool) ([]Token, error) {
+	var tokens []Token
+	for pos := 0; pos < len(p); {
+		tok, err := nextToken(p, pos, registered)
+		if err != nil {
+			return nil, fmt.Errorf("failed to tokenize pattern: %w", err)
+		}
+		tokens = append(tokens, tok)
+		pos = tok.End
+	}
+	return tokens, nil
+}
+
+// NewFromTokens creates a new Strftime object from a list of tokens,
+// such as those returned by Tokenize. The pattern of the resulting
+// object is the concatenation of the textual representation of each token.
+//
+// Explain and Parse use the given tokens, even if the pattern would be
+// tokenized differently.
+func NewFromTokens(tokens []Token, options ...Option) (*Strftime, error) {
+	cfg, err := newCompileConfig(options...)
+	if err != nil {
+		return nil, err
+	}
+
+	var h appenderListBuilder
+	h.list = &combiningAppend{}
+
+	if err := compileTokens(&h, tokens, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to compile tokens: %w", err)
+	}
+
+	// the caller may modify the slice afterwards
+	compiled := make([]Token, len(tokens))
+	var sb strings.Builder
+	for i, tok := range tokens {
+		// tokens that were created by hand may not have their text
+		if tok.Text == "" {
+			tok.Text = tok.String()
+		}
+		compiled[i] = tok
+		sb.WriteString(tok.String())
+	}
+
+	return &Strftime{
+		pattern:  sb.String(),
+		compiled: h.list.list,
+		cfg:      cfg,
+		tokens:   compiled,
+	}, nil
+}
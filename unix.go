@@ -0,0 +1,460 @@
+package strftime
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// civil is the broken down representation of a point in time, in a
+// given location. AppendUnix computes it once, and shares it among the
+// Appenders, instead of constructing a time.Time and having each Appender
+// compute the fields that it needs.
+type civil struct {
+	sec  int64
+	nsec int64
+	loc  *time.Location
+
+	year    int
+	month   time.Month
+	day     int
+	weekday time.Weekday
+	hour    int
+	min     int
+	second  int
+	zone    string
+	offset  int
+
+	// lazily constructed, for Appenders that do not support civil
+	t     time.Time
+	tInit bool
+}
+
+func (c *civil) time() time.Time {
+	if !c.tInit {
+		c.t = time.Unix(c.sec, c.nsec).In(c.loc)
+		c.tInit = true
+	}
+	return c.t
+}
+
+// civilAppender is implemented by the built-in Appenders that can render
+// from a civil breakdown. If the second return value is false, nothing
+// was appended, and the caller must fall back to Append
+type civilAppender interface {
+	appendCivil([]byte, *civil) ([]byte, bool)
+}
+
+func appendCivil(b []byte, a Appender, c *civil) []byte {
+	if ca, ok := a.(civilAppender); ok {
+		if out, ok := ca.appendCivil(b, c); ok {
+			return out
+		}
+	}
+	return a.Append(b, c.time())
+}
+
+// civilCache keeps the zone and the date that were computed last, so that
+// consecutive timestamps, which tend to be close to each other, do not
+// need to look them up again
+type civilCache struct {
+	loc *time.Location
+
+	zoneValid bool
+	zoneStart int64
+	zoneEnd   int64
+	zone      string
+	offset    int
+
+	dateValid bool
+	days      int64
+	year      int
+	month     time.Month
+	day       int
+}
+
+func (cc *civilCache) lookupZone(sec int64) {
+	if cc.zoneValid && sec >= cc.zoneStart && sec < cc.zoneEnd {
+		return
+	}
+	t := time.Unix(sec, 0).In(cc.loc)
+	cc.zone, cc.offset = t.Zone()
+	start, end := t.ZoneBounds()
+	cc.zoneStart = math.MinInt64
+	if !start.IsZero() {
+		cc.zoneStart = start.Unix()
+	}
+	cc.zoneEnd = math.MaxInt64
+	if !end.IsZero() {
+		cc.zoneEnd = end.Unix()
+	}
+	cc.zoneValid = true
+}
+
+// civilFromDays converts the number of days since 1970-01-01 to a date
+// in the proleptic Gregorian calendar
+func civilFromDays(days int64) (int, time.Month, int) {
+	z := days + 719468
+	era := z / 146097
+	if z < 0 && z%146097 != 0 {
+		era--
+	}
+	doe := z - era*146097
+	yoe := (doe - doe/1460 + doe/36524 - doe/146096) / 365
+	y := yoe + era*400
+	doy := doe - (365*yoe + yoe/4 - yoe/100)
+	mp := (5*doy + 2) / 153
+	d := doy - (153*mp+2)/5 + 1
+	m := mp + 3
+	if m > 12 {
+		m -= 12
+	}
+	if m <= 2 {
+		y++
+	}
+	return int(y), time.Month(m), int(d)
+}
+
+func (cc *civilCache) compute(c *civil, sec, nsec int64) {
+	cc.lookupZone(sec)
+
+	local := sec + int64(cc.offset)
+	days := local / 86400
+	rem := local % 86400
+	if rem < 0 {
+		days--
+		rem += 86400
+	}
+
+	if !cc.dateValid || cc.days != days {
+		cc.year, cc.month, cc.day = civilFromDays(days)
+		cc.days = days
+		cc.dateValid = true
+	}
+
+	*c = civil{
+		sec:     sec,
+		nsec:    nsec,
+		loc:     cc.loc,
+		year:    cc.year,
+		month:   cc.month,
+		day:     cc.day,
+		weekday: time.Weekday(((days+4)%7 + 7) % 7), // 1970-01-01 was a Thursday
+		hour:    int(rem / 3600),
+		min:     int(rem % 3600 / 60),
+		second:  int(rem % 60),
+		zone:    cc.zone,
+		offset:  cc.offset,
+	}
+}
+
+// civil escapes to the heap, as it is passed to the Appenders
+var civilPool = sync.Pool{
+	New: func() interface{} {
+		return &civil{}
+	},
+}
+
+func (f *Strftime) civilCache(loc *time.Location) civilCache {
+	if f.cfg.loc != nil {
+		loc = f.cfg.loc
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	return civilCache{loc: loc}
+}
+
+func (f *Strftime) appendCivil(b []byte, c *civil) []byte {
+	for _, w := range f.compiled {
+		b = appendCivil(b, w, c)
+	}
+	return b
+}
+
+// AppendUnix formats the time given as seconds and nanoseconds since the
+// unix epoch in the location `loc`, and appends the result to `dst`. It
+// produces the same output as FormatBuffer(dst, time.Unix(sec, nsec).In(loc)),
+// but the built-in specifications render from a date and time that is
+// computed only once, without constructing a time.Time.
+//
+// If `loc` is nil, UTC is used. If the Strftime object was created with
+// WithLocation, that location is used instead of `loc`.
+func (f *Strftime) AppendUnix(dst []byte, sec, nsec int64, loc *time.Location) []byte {
+	// normalize, as time.Unix does
+	if nsec < 0 || nsec >= int64(time.Second) {
+		sec += nsec / int64(time.Second)
+		nsec %= int64(time.Second)
+		if nsec < 0 {
+			nsec += int64(time.Second)
+			sec--
+		}
+	}
+
+	cc := f.civilCache(loc)
+	c := civilPool.Get().(*civil)
+	cc.compute(c, sec, nsec)
+	dst = f.appendCivil(dst, c)
+	civilPool.Put(c)
+	return dst
+}
+
+// AppendUnixNanos formats each of the times given as nanoseconds since the
+// unix epoch in the location `loc`, and appends the results to `dst`. The
+// second return value contains the offsets of each result in the returned
+// slice: the i-th result is out[offsets[i]:offsets[i+1]].
+//
+// The zone and the date are reused between consecutive timestamps when
+// possible, which makes this considerably faster than calling AppendUnix
+// for each value when the timestamps are close to each other.
+func (f *Strftime) AppendUnixNanos(dst []byte, ns []int64, loc *time.Location) ([]byte, []int) {
+	cc := f.civilCache(loc)
+	offsets := make([]int, 0, len(ns)+1)
+	var c civil
+	for _, v := range ns {
+		offsets = append(offsets, len(dst))
+		sec := v / int64(time.Second)
+		nsec := v % int64(time.Second)
+		if nsec < 0 {
+			nsec += int64(time.Second)
+			sec--
+		}
+		cc.compute(&c, sec, nsec)
+		dst = f.appendCivil(dst, &c)
+	}
+	offsets = append(offsets, len(dst))
+	return dst, offsets
+}
+
+func (v verbatimw) appendCivil(b []byte, _ *civil) ([]byte, bool) {
+	return append(b, v.s...), true
+}
+
+func (v hourPadded) appendCivil(b []byte, c *civil) ([]byte, bool) {
+	h := c.hour
+	if v.twelveHour && h > 12 {
+		h = h - 12
+	}
+	if v.twelveHour && h == 0 {
+		h = 12
+	}
+	if h < 10 {
+		return append(b, v.pad, byte(h+48)), true
+	}
+	return unrollTwoDigits(b, h), true
+}
+
+func (v weekday) appendCivil(b []byte, c *civil) ([]byte, bool) {
+	n := int(c.weekday)
+	if n < int(v) {
+		n += 7
+	}
+	return append(b, byte(n+48)), true
+}
+
+func (v hmsWAMPM) appendCivil(b []byte, c *civil) ([]byte, bool) {
+	h := c.hour % 12
+	if h == 0 {
+		h = 12
+	}
+	b = unrollTwoDigits(b, h)
+	b = append(b, ':')
+	b = unrollTwoDigits(b, c.min)
+	b = append(b, ':')
+	b = unrollTwoDigits(b, c.second)
+	if c.hour < 12 {
+		return append(b, " AM"...), true
+	}
+	return append(b, " PM"...), true
+}
+
+func (millisecondsAppender) appendCivil(b []byte, c *civil) ([]byte, bool) {
+	ms := int(c.nsec / int64(time.Millisecond))
+	b = append(b, byte('0'+ms/100))
+	return unrollTwoDigits(b, ms%100), true
+}
+
+func (v fractionalSecondsAppender) appendCivil(b []byte, c *civil) ([]byte, bool) {
+	div := int64(100000000)
+	for i := 0; i < 9; i++ {
+		b = append(b, byte('0'+(c.nsec/div)%10))
+		div /= 10
+	}
+	return b, true
+}
+
+func (v flaggedAppender) appendCivil(b []byte, c *civil) ([]byte, bool) {
+	// Appenders that interpret the width by themselves need a time.Time
+	if _, ok := v.appender.(widthAppender); ok && v.width > 0 {
+		return b, false
+	}
+	ca, ok := v.appender.(civilAppender)
+	if !ok {
+		return b, false
+	}
+	start := len(b)
+	b, ok = ca.appendCivil(b, c)
+	if !ok {
+		return b, false
+	}
+	return applyFlags(b, start, v.flags, v.width), true
+}
+
+// the layout elements that stdlibFormat can render from a civil
+// breakdown. Longer elements must precede their prefixes
+const (
+	civilLiteral = iota
+	civilLongMonth
+	civilMonth
+	civilLongWeekDay
+	civilWeekDay
+	civilZone
+	civilYear
+	civilNumTZ
+	civilZeroMonth
+	civilZeroDay
+	civilUnderDay
+	civilHour
+	civilZeroMinute
+	civilZeroSecond
+	civilYearNoCentury
+	civilPM
+)
+
+var civilLayoutElements = []struct {
+	text string
+	kind int
+}{
+	{`January`, civilLongMonth},
+	{`Jan`, civilMonth},
+	{`Monday`, civilLongWeekDay},
+	{`Mon`, civilWeekDay},
+	{`MST`, civilZone},
+	{`2006`, civilYear},
+	{`-0700`, civilNumTZ},
+	{`01`, civilZeroMonth},
+	{`02`, civilZeroDay},
+	{`_2`, civilUnderDay},
+	{`15`, civilHour},
+	{`04`, civilZeroMinute},
+	{`05`, civilZeroSecond},
+	{`06`, civilYearNoCentury},
+	{`PM`, civilPM},
+}
+
+type civilLayoutElement struct {
+	kind int
+	// for civilLiteral
+	text string
+}
+
+// compileCivilLayout splits a time.Format layout into elements for
+// appendCivil. It returns nil if the layout contains elements that are
+// not supported, which are left to time.Format
+func compileCivilLayout(layout string) []civilLayoutElement {
+	if strings.Contains(layout, `__`) {
+		return nil
+	}
+
+	var elems []civilLayoutElement
+	literal := 0
+	for i := 0; i < len(layout); {
+		kind := civilLiteral
+		var n int
+		for _, e := range civilLayoutElements {
+			if strings.HasPrefix(layout[i:], e.text) {
+				kind, n = e.kind, len(e.text)
+				break
+			}
+		}
+		if kind == civilLiteral {
+			// anything else that may be a layout element, such as `3`
+			// or `Z07:00`, is left to time.Format
+			if ch := layout[i]; (ch >= '0' && ch <= '9') || ch == 'Z' || ch == 'p' {
+				return nil
+			}
+			i++
+			continue
+		}
+		if literal < i {
+			elems = append(elems, civilLayoutElement{kind: civilLiteral, text: layout[literal:i]})
+		}
+		elems = append(elems, civilLayoutElement{kind: kind})
+		i += n
+		literal = i
+	}
+	if literal < len(layout) {
+		elems = append(elems, civilLayoutElement{kind: civilLiteral, text: layout[literal:]})
+	}
+	if elems == nil {
+		// an empty layout, which is supported
+		elems = []civilLayoutElement{}
+	}
+	return elems
+}
+
+func (v stdlibFormat) appendCivil(b []byte, c *civil) ([]byte, bool) {
+	if v.civil == nil || c.year < 0 || c.year > 9999 {
+		return b, false
+	}
+
+	start := len(b)
+	for _, elem := range v.civil {
+		switch elem.kind {
+		case civilLiteral:
+			b = append(b, elem.text...)
+		case civilLongMonth:
+			b = append(b, c.month.String()...)
+		case civilMonth:
+			b = append(b, c.month.String()[:3]...)
+		case civilLongWeekDay:
+			b = append(b, c.weekday.String()...)
+		case civilWeekDay:
+			b = append(b, c.weekday.String()[:3]...)
+		case civilZone:
+			if c.zone == `` {
+				return b[:start], false
+			}
+			b = append(b, c.zone...)
+		case civilYear:
+			b = unrollTwoDigits(b, c.year/100)
+			b = unrollTwoDigits(b, c.year%100)
+		case civilNumTZ:
+			offset := c.offset / 60
+			if offset < 0 {
+				b = append(b, '-')
+				offset = -offset
+			} else {
+				b = append(b, '+')
+			}
+			b = unrollTwoDigits(b, offset/60)
+			b = unrollTwoDigits(b, offset%60)
+		case civilZeroMonth:
+			b = unrollTwoDigits(b, int(c.month))
+		case civilZeroDay:
+			b = unrollTwoDigits(b, c.day)
+		case civilUnderDay:
+			if c.day < 10 {
+				b = append(b, ' ', byte('0'+c.day))
+			} else {
+				b = unrollTwoDigits(b, c.day)
+			}
+		case civilHour:
+			b = unrollTwoDigits(b, c.hour)
+		case civilZeroMinute:
+			b = unrollTwoDigits(b, c.min)
+		case civilZeroSecond:
+			b = unrollTwoDigits(b, c.second)
+		case civilYearNoCentury:
+			b = unrollTwoDigits(b, c.year%100)
+		case civilPM:
+			if c.hour >= 12 {
+				b = append(b, "PM"...)
+			} else {
+				b = append(b, "AM"...)
+			}
+		}
+	}
+	return b, true
+}